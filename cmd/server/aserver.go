@@ -3,12 +3,15 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -17,15 +20,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
 	"go.uber.org/automaxprocs/maxprocs"
+	"golang.org/x/crypto/acme"
 	"golang.org/x/crypto/acme/autocert"
 	"snowdream.tech/http-server/middlewares"
 	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/db"
+	"snowdream.tech/http-server/pkg/downloads"
 	"snowdream.tech/http-server/pkg/env"
+	"snowdream.tech/http-server/pkg/health"
 	glog "snowdream.tech/http-server/pkg/log"
 	gnet "snowdream.tech/http-server/pkg/net"
 	ghttp "snowdream.tech/http-server/pkg/net/http"
 	ghttps "snowdream.tech/http-server/pkg/net/https"
 	"snowdream.tech/http-server/pkg/tools"
+	"snowdream.tech/http-server/pkg/tracing"
 )
 
 var (
@@ -63,10 +71,45 @@ func init() {
 
 			welcome()
 
-			tools.DebugPrintF("[INFO] Starting Web Server %s", env.ProjectName)
-			tools.DebugPrintF("[INFO] Args: %s", strings.Join(args, " "))
+			logger := tools.Logger("server")
 
-			// db.Open()
+			logger.Info("Starting Web Server", "name", env.ProjectName, "args", strings.Join(args, " "))
+
+			if tracingConf := configs.GetTracingConfig(); tracingConf.Enable {
+				shutdown, err := tracing.Init(context.Background(), tracingConf)
+
+				if err != nil {
+					logger.Error("Failed to start the tracing", "error", err)
+				} else {
+					defer shutdown(context.Background())
+				}
+			}
+
+			if dbConf := configs.GetDatabaseConfig(); dbConf.Enable {
+				database, err := db.Open(dbConf)
+
+				if err != nil {
+					tools.Fatal(logger, "Failed to open the database", "type", dbConf.Type, "error", err)
+				}
+
+				defer database.Close()
+
+				store, err := downloads.NewStore(database)
+
+				if err != nil {
+					tools.Fatal(logger, "Failed to create the download tables", "error", err)
+				}
+
+				// the queued downloads are written before the database is closed,
+				// gracefulStart returns even when the servers are forced to shutdown
+				defer store.Close()
+
+				downloads.SetDefault(store)
+
+				health.Register("database", func(ctx context.Context) error {
+					return database.PingContext(ctx)
+				})
+			}
 
 			gHandler := gin.New()
 
@@ -85,15 +128,37 @@ func init() {
 			// See the PR #1817 and issue #1644
 			gHandler.RemoveExtraSlash = true
 
+			// The client ip is taken from the headers of the trusted proxies only,
+			// otherwise anyone could pick theirs, eg: an allow-listed one.
+			if err := gHandler.SetTrustedProxies(gnet.TrustedProxies(app.TrustedProxies)); err != nil {
+				tools.Fatal(logger, "Invalid trusted proxies", "error", err)
+			}
+
 			gHandler.Use(middlewares.Configs(conf))
+			gHandler.Use(middlewares.RequestID())
+			gHandler.Use(middlewares.HSTS())
+			gHandler.Use(middlewares.Tracing())
 			gHandler.Use(middlewares.LoggerWithFormatter())
+			gHandler.Use(middlewares.I18N())
+			gHandler.Use(middlewares.Health())
+			gHandler.Use(middlewares.BruteForce())
+			gHandler.Use(middlewares.Metrics())
+			gHandler.Use(middlewares.Maintenance())
+			gHandler.Use(middlewares.Share())
 			gHandler.Use(middlewares.BasicAuth())
 			gHandler.Use(middlewares.Cors())
 			gHandler.Use(middlewares.Referer())
-			gHandler.Use(middlewares.I18N())
+			gHandler.Use(middlewares.ClientCert())
+			gHandler.Use(middlewares.JWT())
+			gHandler.Use(middlewares.OIDC())
+			gHandler.Use(middlewares.Admin())
+			gHandler.Use(middlewares.AuditDownloads())
+			gHandler.Use(middlewares.Stats())
 			gHandler.Use(middlewares.Size())
 			gHandler.Use(middlewares.RateLimiter())
+			gHandler.Use(middlewares.SharedFile())
 			gHandler.Use(middlewares.Gzip())
+			gHandler.Use(middlewares.CompressionMetrics())
 			gHandler.Use(middlewares.Header())
 			gHandler.Use(middlewares.XMLHeader())
 			gHandler.Use(gin.Recovery())
@@ -112,7 +177,9 @@ func init() {
 			var addrHTTP string
 			// var addrHTTPS string
 
-			if port != "" {
+			if len(app.Listen) > 0 {
+				addrHTTP = app.Listen[0]
+			} else if port != "" {
 				addrHTTP = host + ":" + port
 			} else {
 				// Get the free port from 8080
@@ -128,8 +195,18 @@ func init() {
 			}
 
 			if !app.EnableHTTPS {
-				tools.DebugPrintF("[INFO] HTTPS was disabled.")
-				gracefulStart(httpServer)
+				logger.Info("HTTPS was disabled.")
+
+				if app.H2C {
+					if err := serveH2C(httpServer, app); err != nil {
+						tools.Fatal(logger, "Invalid HTTP/2 settings", "error", err)
+					}
+				}
+
+				if err := gracefulStart(nil, withMetricsServer(conf, newListenServer(httpServer, app.Listen))...); err != nil {
+					logger.Error("The Web Servers forced to shutdown", "error", err)
+				}
+
 				return
 			}
 
@@ -138,7 +215,9 @@ func init() {
 
 			var addrHTTPS string
 
-			if httpsport != "" {
+			if len(app.HTTPSListen) > 0 {
+				addrHTTPS = app.HTTPSListen[0]
+			} else if httpsport != "" {
 				addrHTTPS = host + ":" + httpsport
 			} else {
 				// Get the free port from 8443
@@ -146,65 +225,226 @@ func init() {
 			}
 
 			// Load cert and key
-			var certPEMBlock, keyPEMBlock []byte
 			var err error
 			var cert tls.Certificate
+			var certificates ghttps.Certificates
 			var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+			var certManager *autocert.Manager
 
-			// load From Local Cert
-			if app.HTTPSCertFile != "" && app.HTTPSKeyFile != "" {
-				cert, err = tls.LoadX509KeyPair(app.HTTPSCertFile, app.HTTPSKeyFile)
-			} else {
-				err = errors.New("app.HTTPSCertFile is Empty or app.HTTPSKeyFile is empty")
+			// ACME, the domains, the email and the cache default to the https flags
+			acmeConf := *configs.GetACMEConfig()
+
+			if len(acmeConf.Domains) == 0 {
+				acmeConf.Domains = app.HTTPSDomains
 			}
 
-			// load From Auto Cert
-			if err != nil {
-				if app.Port == "80" && app.HTTPSPort == "443" && app.HTTPSDomains != nil && len(app.HTTPSDomains) > 0 && app.ContactEmail != "" {
-					httpscertsdir := "certs"
+			if acmeConf.Email == "" {
+				acmeConf.Email = app.ContactEmail
+			}
 
-					if app.HTTPSCertsDir != "" {
-						httpscertsdir = app.HTTPSCertsDir
-					}
+			if acmeConf.CacheDir == "" {
+				acmeConf.CacheDir = app.HTTPSCertsDir
+			}
 
-					certManager := autocert.Manager{
-						Prompt:     autocert.AcceptTOS,
-						HostPolicy: autocert.HostWhitelist(app.HTTPSDomains...), //your domain here
-						Cache:      autocert.DirCache(httpscertsdir),            //folder for storing certificates
-						Email:      app.ContactEmail,
-					}
+			// load From Local Certs, selected by SNI, app.httpscertfile is the default one.
+			// They are reloaded when the files change, on SIGHUP, or by the admin api.
+			if app.HTTPSCertFile != "" && app.HTTPSKeyFile != "" {
+				certificate, err := ghttps.LoadCertificate(app.HTTPSCertFile, app.HTTPSKeyFile)
 
-					getCertificate = certManager.GetCertificate
+				if err != nil {
+					logger.Warn("Failed to load the certificate", "file", app.HTTPSCertFile, "error", err)
+				} else {
+					certificates.Add(certificate)
 				}
 			}
 
-			// load From Embde Cert
-			if err != nil && getCertificate == nil {
-				certPEMBlock, err = ghttps.GetTLSCerts().ReadFile("certs/server.pem")
+			tlsConf := configs.GetTLSConfig()
+
+			for _, pair := range tlsConf.Certificates {
+				certificate, err := ghttps.LoadCertificate(pair.CertFile, pair.KeyFile)
 
 				if err != nil {
-					log.Fatal(err)
+					tools.Fatal(logger, "Failed to load the certificate", "file", pair.CertFile, "error", err)
 				}
 
-				keyPEMBlock, err = ghttps.GetTLSCerts().ReadFile("certs/server.key")
+				certificates.Add(certificate)
+			}
+
+			if tlsConf.CertificatesDir != "" {
+				list, err := ghttps.LoadCertificatesDir(tlsConf.CertificatesDir)
 
 				if err != nil {
-					log.Fatal(err)
+					tools.Fatal(logger, "Failed to load the certificates", "dir", tlsConf.CertificatesDir, "error", err)
 				}
 
-				cert, err = tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+				certificates.Add(list...)
+			}
+
+			for _, certificate := range certificates.List() {
+				if watcher, err := certificate.Watch(); err != nil {
+					logger.Warn("Failed to watch the certificate, it is only reloaded on SIGHUP", "file", certificate.CertFile, "error", err)
+				} else {
+					defer watcher.Close()
+				}
+			}
+
+			// The OCSP responses are stapled to the handshakes of the local certs
+			if app.OCSPStapling && len(certificates.List()) > 0 {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				stapler := &ghttps.OCSPStapler{CacheDir: app.HTTPSCertsDir}
+
+				for _, certificate := range certificates.List() {
+					go stapler.Run(ctx, certificate)
+				}
+
+				health.Register("ocsp", ocspCheck(certificates.List()))
+			}
+
+			// load From ACME, for the names without a local cert, on any ports, the CA reaches them through port forwarding
+			if acmeConf.Enable || (len(certificates.List()) == 0 && len(acmeConf.Domains) > 0 && acmeConf.Email != "") {
+				certManager, err = ghttps.NewACMEManager(&acmeConf)
 
 				if err != nil {
-					log.Fatal(err)
+					tools.Fatal(logger, "Invalid ACME configuration", "error", err)
 				}
+
+				certificates.ACME = certManager
+
+				logger.Info("The certificates are obtained by ACME", "domains", acmeConf.Domains, "directory", certManager.Client.DirectoryURL)
+			}
+
+			if len(certificates.List()) > 0 || certManager != nil {
+				getCertificate = certificates.GetCertificate
+			} else {
+				// a self-signed certificate, unique to this installation, created on first start
+				hosts := ghttps.DefaultHosts(append(gnet.GetAvailableIPS(), app.HTTPSDomains...)...)
+
+				pair, err := ghttps.LoadOrCreateSelfSigned(filepath.Join(app.HTTPSCertsDir, "selfsigned"), hosts)
+
+				if pair == nil {
+					tools.Fatal(logger, "Failed to create a self-signed certificate", "error", err)
+				}
+
+				if err != nil {
+					logger.Warn("Failed to save the self-signed certificate, a new one is created on every start", "dir", app.HTTPSCertsDir, "error", err)
+				}
+
+				cert = pair.TLSCertificate()
+
+				logger.Warn("Using a self-signed certificate, run the cert command to issue one signed by a local CA", "hosts", hosts, "expiry", pair.Cert.NotAfter)
 			}
 
 			// Construct a tls.config
 			tlsConfig := &tls.Config{
-				Certificates:   []tls.Certificate{cert},
-				GetCertificate: getCertificate,
-				MinVersion:     tls.VersionTLS12,
-				MaxVersion:     tls.VersionTLS13,
+				GetCertificate:         getCertificate,
+				SessionTicketsDisabled: !app.TLSSessionTickets,
+			}
+
+			if cert.Certificate != nil {
+				tlsConfig.Certificates = []tls.Certificate{cert}
+			}
+
+			policy, err := tlsPolicy(app)
+
+			if err != nil {
+				tools.Fatal(logger, "Invalid TLS policy", "error", err)
+			}
+
+			policy.Apply(tlsConfig)
+
+			tlsConfig.NextProtos, err = alpnProtocols(app)
+
+			if err != nil {
+				tools.Fatal(logger, "Invalid TLS policy", "error", err)
+			}
+
+			// The tls-alpn-01 challenges are answered by GetCertificate
+			if certManager != nil {
+				tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+			}
+
+			// Without it, the session ticket keys are rotated every day
+			if app.TLSSessionTickets && app.TLSTicketRotation > 0 {
+				stop, err := ghttps.RotateSessionTicketKeys(tlsConfig, time.Duration(app.TLSTicketRotation)*time.Second)
+
+				if err != nil {
+					tools.Fatal(logger, "Failed to create the session ticket keys", "error", err)
+				}
+
+				defer stop()
+			}
+
+			logger.Info("TLS policy", "policy", policy, "alpn", tlsConfig.NextProtos,
+				"session_tickets", app.TLSSessionTickets, "ticket_rotation", time.Duration(app.TLSTicketRotation)*time.Second)
+
+			// Client certificates
+			tlsConfig.ClientAuth, err = clientAuthType(app.HTTPSClientAuth)
+
+			if err != nil {
+				tools.Fatal(logger, "Invalid client auth", "error", err)
+			}
+
+			if err := checkClientPaths(tlsConfig.ClientAuth, app.HTTPSClientPaths); err != nil {
+				tools.Fatal(logger, "Invalid client auth", "error", err)
+			}
+
+			if app.HTTPSClientCAFile != "" {
+				tlsConfig.ClientCAs, err = loadCertPool(app.HTTPSClientCAFile)
+
+				if err != nil {
+					tools.Fatal(logger, "Failed to load the client CA file", "file", app.HTTPSClientCAFile, "error", err)
+				}
+			} else if tlsConfig.ClientAuth >= tls.VerifyClientCertIfGiven {
+				tools.Fatal(logger, "--https-client-ca-file is required to verify client certificates")
+			}
+
+			if len(certificates.List()) > 0 || cert.Certificate != nil {
+				health.Register("certificate", health.CertificateCheck(func() []*x509.Certificate {
+					certs := []tls.Certificate{}
+
+					for _, certificate := range certificates.List() {
+						certs = append(certs, *certificate.Certificate())
+					}
+
+					if cert.Certificate != nil {
+						certs = append(certs, cert)
+					}
+
+					return certificateLeaves(certs...)
+				}, time.Duration(configs.GetHealthConfig().CertExpiry)*time.Second))
+			}
+
+			// The http server only redirects to https, and answers the ACME http-01 challenges
+			if app.HTTPSRedirect {
+				var port string
+
+				addrs := app.HTTPSListen
+
+				if len(addrs) == 0 {
+					addrs = []string{addrHTTPS}
+				}
+
+				// the port of the first host:port, 443 behind unix or inherited sockets
+				for _, addr := range addrs {
+					if gnet.IsTCPAddress(addr) {
+						_, port, _ = net.SplitHostPort(addr)
+						break
+					}
+				}
+
+				httpServer.Handler = ghttps.RedirectHandler(port)
+			}
+
+			if certManager != nil {
+				httpServer.Handler = certManager.HTTPHandler(httpServer.Handler)
+			}
+
+			if app.H2C {
+				if err := serveH2C(httpServer, app); err != nil {
+					tools.Fatal(logger, "Invalid HTTP/2 settings", "error", err)
+				}
 			}
 
 			httpsServer := &http.Server{
@@ -216,7 +456,36 @@ func init() {
 				MaxHeaderBytes: 1 << 20,
 			}
 
-			gracefulStart(httpServer, httpsServer)
+			httpsListen := newListenServer(httpsServer, app.HTTPSListen)
+
+			// HTTP/3 on the udp port of https, advertised by the Alt-Svc header
+			var h3 *http3Listener
+
+			if app.HTTP3 {
+				if policy.MaxVersion < tls.VersionTLS13 {
+					tools.Fatal(logger, "HTTP/3 requires TLS 1.3", "max_version", tls.VersionName(policy.MaxVersion))
+				}
+
+				addrs := httpsListen.tcpAddresses()
+
+				if len(addrs) == 0 {
+					tools.Fatal(logger, "HTTP/3 requires a host:port address of HTTPS", "addrs", httpsListen.addrs)
+				}
+
+				h3 = newHTTP3Listener(addrs, tlsConfig, gHandler)
+				httpsServer.Handler = h3.AltSvc(gHandler)
+			}
+
+			// A non nil map disables http/2
+			if !slices.Contains(tlsConfig.NextProtos, "h2") {
+				httpsServer.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+			} else if err := configureHTTP2(httpsServer, app); err != nil {
+				tools.Fatal(logger, "Invalid HTTP/2 settings", "error", err)
+			}
+
+			if err := gracefulStart(h3, withMetricsServer(conf, newListenServer(httpServer, app.Listen), httpsListen)...); err != nil {
+				logger.Error("The Web Servers forced to shutdown", "error", err)
+			}
 		},
 	}
 
@@ -250,6 +519,22 @@ A zero or negative value means there will be no timeout.`)
 
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.HTTPSPort, "https-port", "", configs.GetConfigs().App.HTTPSPort, `HTTPS PORT`)
 
+	rootCmd.Flags().StringArrayVarP(&configs.GetConfigs().App.Listen, "listen", "", configs.GetConfigs().App.Listen, `The addresses of HTTP, instead of --host and --port, it can be repeated:
+host:port, 0.0.0.0:port (IPv4 only), [::]:port (IPv6 only), unix:/path/to/socket, fd:N (an inherited file descriptor),
+systemd (all the sockets of systemd socket activation), systemd:name (by FileDescriptorName) or systemd:N.
+The peer of a unix socket is seen as 0.0.0.0, add unix to --trusted-proxies to take the client ip
+from the X-Forwarded-For of the reverse proxy.`)
+
+	rootCmd.Flags().StringArrayVarP(&configs.GetConfigs().App.HTTPSListen, "https-listen", "", configs.GetConfigs().App.HTTPSListen, `The addresses of HTTPS, instead of --host and --https-port, it can be repeated, see --listen.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.SocketMode, "socket-mode", "", configs.GetConfigs().App.SocketMode, `The file mode of the unix sockets, in octal.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.SocketOwner, "socket-owner", "", configs.GetConfigs().App.SocketOwner, `The owner of the unix sockets: user, user:group or :group.`)
+
+	rootCmd.Flags().StringSliceVarP(&configs.GetConfigs().App.TrustedProxies, "trusted-proxies", "", configs.GetConfigs().App.TrustedProxies, `The ips or CIDRs of the reverse proxies whose X-Forwarded-For and X-Real-IP give the client ip,
+and unix for the peers of the unix sockets. By default there are none, the client ip is the peer of the connection,
+which the brute-force protection, the limiters and the logs rely on.`)
+
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.HTTPSCertFile, "https-cert-file", "", configs.GetConfigs().App.HTTPSCertFile, `HTTPS Cert File`)
 
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.HTTPSKeyFile, "https-key-file", "", configs.GetConfigs().App.HTTPSKeyFile, `HTTPS Key File`)
@@ -260,6 +545,84 @@ A zero or negative value means there will be no timeout.`)
 
 	rootCmd.Flags().StringArrayVarP(&configs.GetConfigs().App.HTTPSDomains, "https-domains", "", configs.GetConfigs().App.HTTPSDomains, `HTTPS Domains`)
 
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().ACME.Enable, "acme", "", configs.GetConfigs().ACME.Enable, `If it is set, the certificates of --https-domains are obtained by ACME,
+except for the names of the certificate files, which are selected by SNI.
+
+Without it, ACME is used when --https-domains and --contact-email are set, and there is no certificate file.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().TLS.CertificatesDir, "tls-certificates-dir", "", configs.GetConfigs().TLS.CertificatesDir, `A directory of <name>.crt and <name>.key pairs, or of <name>/fullchain.pem and <name>/privkey.pem pairs.
+The certificate of a connection is selected by its server name (SNI), wildcards included,
+--https-cert-file is the default one.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().ACME.DirectoryURL, "acme-directory-url", "", configs.GetConfigs().ACME.DirectoryURL, `The ACME directory of the CA. If it is empty, Let's Encrypt is used.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().ACME.CAFile, "acme-ca-file", "", configs.GetConfigs().ACME.CAFile, `A PEM bundle trusted for the connections to the ACME CA, eg: a private CA`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().ACME.EABKeyID, "acme-eab-key-id", "", configs.GetConfigs().ACME.EABKeyID, `The key id of the External Account Binding required by some ACME CAs`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().ACME.EABHMACKey, "acme-eab-hmac-key", "", configs.GetConfigs().ACME.EABHMACKey, `The base64url encoded HMAC key of the External Account Binding`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.HTTPSRedirect, "https-redirect", "", configs.GetConfigs().App.HTTPSRedirect, `If it is set, the HTTP server only redirects to HTTPS, keeping the path and the query,
+and answers the ACME HTTP-01 challenges.`)
+
+	rootCmd.Flags().Int64VarP(&configs.GetConfigs().App.HSTSMaxAge, "hsts-max-age", "", configs.GetConfigs().App.HSTSMaxAge, `The max-age, in seconds, of the Strict-Transport-Security header of the HTTPS responses.
+If it is 0, the header is not sent.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.HSTSIncludeSubdomains, "hsts-include-subdomains", "", configs.GetConfigs().App.HSTSIncludeSubdomains, `If it is set, the Strict-Transport-Security header also applies to the subdomains.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.HSTSPreload, "hsts-preload", "", configs.GetConfigs().App.HSTSPreload, `If it is set, the Strict-Transport-Security header asks to be preloaded by the browsers.
+It requires --hsts-include-subdomains and a --hsts-max-age of at least 31536000.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.TLSPolicy, "tls-policy", "", configs.GetConfigs().App.TLSPolicy, `The TLS preset, after the Mozilla guidelines:
+modern (TLS 1.3), intermediate (TLS 1.2 and 1.3) or legacy (TLS 1.0 to 1.3).
+The options below override it.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.TLSMinVersion, "tls-min-version", "", configs.GetConfigs().App.TLSMinVersion, `The min TLS version: 1.0, 1.1, 1.2 or 1.3`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.TLSMaxVersion, "tls-max-version", "", configs.GetConfigs().App.TLSMaxVersion, `The max TLS version: 1.0, 1.1, 1.2 or 1.3`)
+
+	rootCmd.Flags().StringSliceVarP(&configs.GetConfigs().App.TLSCipherSuites, "tls-cipher-suites", "", configs.GetConfigs().App.TLSCipherSuites, `The cipher suites of TLS 1.0 to 1.2, by their IANA names, eg: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+The cipher suites of TLS 1.3 are not configurable.`)
+
+	rootCmd.Flags().StringSliceVarP(&configs.GetConfigs().App.TLSCurves, "tls-curves", "", configs.GetConfigs().App.TLSCurves, `The curves, by order of preference: X25519, P-256, P-384 or P-521`)
+
+	rootCmd.Flags().StringSliceVarP(&configs.GetConfigs().App.TLSALPN, "tls-alpn", "", configs.GetConfigs().App.TLSALPN, `The application protocols, by order of preference: h2 and http/1.1.
+Without h2, HTTP/2 is disabled.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.TLSSessionTickets, "tls-session-tickets", "", configs.GetConfigs().App.TLSSessionTickets, `If it is set, the sessions can be resumed with session tickets.`)
+
+	rootCmd.Flags().Int64VarP(&configs.GetConfigs().App.TLSTicketRotation, "tls-ticket-rotation", "", configs.GetConfigs().App.TLSTicketRotation, `The rotation of the session ticket keys, in seconds, the last 3 keys are accepted.
+If it is 0, they are rotated every day.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.HTTP3, "http3", "", configs.GetConfigs().App.HTTP3, `If it is set, HTTP/3 (QUIC) is also served on the udp port of HTTPS,
+and advertised to the HTTP/1.1 and HTTP/2 clients by the Alt-Svc header. It requires TLS 1.3.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.H2C, "h2c", "", configs.GetConfigs().App.H2C, `If it is set, HTTP/2 without TLS (h2c) is also served on the HTTP port,
+with prior knowledge or Upgrade, eg: behind a TLS terminating proxy.`)
+
+	rootCmd.Flags().Int64VarP(&configs.GetConfigs().App.HTTP2MaxStreams, "http2-max-concurrent-streams", "", configs.GetConfigs().App.HTTP2MaxStreams, `The max concurrent streams of an HTTP/2 connection, with TLS or h2c.
+If it is 0, it is 250.`)
+
+	rootCmd.Flags().Int64VarP(&configs.GetConfigs().App.HTTP2MaxFrameSize, "http2-max-frame-size", "", configs.GetConfigs().App.HTTP2MaxFrameSize, `The max size of the HTTP/2 frames sent by the clients, from 16384 to 16777215 bytes.
+If it is 0, it is 1048576.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.OCSPStapling, "ocsp-stapling", "", configs.GetConfigs().App.OCSPStapling, `If it is set, the OCSP responses of the certificate files are stapled to the handshakes.
+They are cached in --https-cert-dir, and refreshed in the background.
+
+The responders are contacted at startup and on every refresh. When a response says
+that a certificate is revoked, the "ocsp" readiness check fails: with health.enable,
+the readiness path answers 503 and the server is taken out of the load balancer
+until the certificate is replaced.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.HTTPSClientAuth, "https-client-auth", "", configs.GetConfigs().App.HTTPSClientAuth, `HTTPS Client Certificate policy: none, request, require, verify or require-and-verify.
+
+Use verify together with --https-client-paths to require client certificates only for some paths.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.HTTPSClientCAFile, "https-client-ca-file", "", configs.GetConfigs().App.HTTPSClientCAFile, `HTTPS Client CA bundle, used to verify the client certificates`)
+
+	rootCmd.Flags().StringArrayVarP(&configs.GetConfigs().App.HTTPSClientPaths, "https-client-paths", "", configs.GetConfigs().App.HTTPSClientPaths, `The paths which require a verified client certificate,
+with --https-client-auth verify or require-and-verify.`)
+
 	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.Gzip, "gzip", "g", configs.GetConfigs().App.Gzip, `If it is set, we will compress with gzip.`)
 
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.AutoIndexTimeFormat, "autoindex-time-format", "", configs.GetConfigs().App.AutoIndexTimeFormat, `this is the AutoIndex Time Format.`)
@@ -267,10 +630,29 @@ A zero or negative value means there will be no timeout.`)
 	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.AutoIndexExactSize, "autoindex-exact-size", "", configs.GetConfigs().App.AutoIndexExactSize, `For the HTML format, specifies whether exact file sizes should be output in the directory listing,
 or rather rounded to kilobytes, megabytes, and gigabytes.`)
 
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.AutoIndexDownloads, "autoindex-downloads", "", configs.GetConfigs().App.AutoIndexDownloads, `For the HTML format, specifies whether the download counts recorded in the database
+should be output in the directory listing.`)
+
 	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.PreviewHTML, "preview-html", "", configs.GetConfigs().App.PreviewHTML, `For static web files, Whether preview them.`)
 
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.LogDir, "log-dir", "", configs.GetConfigs().App.LogDir, `The Log Directory which store access.log, error.log etc.`)
 
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.LogLevel, "log-level", "", configs.GetConfigs().App.LogLevel, `The level of gin.log and error.log: debug, info, warn or error.
+If it is not set, debug is used in gin debug mode, and info otherwise.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.LogFormat, "log-format", "", configs.GetConfigs().App.LogFormat, `The format of gin.log and error.log: text or json.`)
+
+	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.AccessLogFormat, "access-log-format", "", configs.GetConfigs().App.AccessLogFormat, `The format of access.log: default, common, combined, json,
+or a text/template such as '{{.RemoteIP}} "{{.Method}} {{.Path}}" {{.Status}} {{.BytesSent}}'.
+
+The template fields are Time, RemoteIP, User, RequestID, Method, Path, Proto, Host,
+Status, BytesSent, Latency (ms), Referer, UserAgent, TLSVersion, UpstreamTime (ms, the calls to the OpenID provider) and Error.`)
+
+	rootCmd.Flags().BoolVarP(&configs.GetConfigs().App.AuditLog, "audit-log", "", configs.GetConfigs().App.AuditLog, `If it is set, logins, authentications and their failures, the downloads of the authenticated users
+with their size, bans, admin operations and configuration changes are appended to audit.log
+in the Log Directory, as JSON lines. A credential is logged once per session, not on every request.
+By default, the rotated audit logs are never deleted, see log.audit in the config file.`)
+
 	rootCmd.Flags().StringVarP(&configs.GetConfigs().App.RateLimiter, "rate-limiter", "", configs.GetConfigs().App.RateLimiter, `Define a limit rate to several requests per hour.
 	You can also use the simplified format "<limit>-<period>"", with the given
 	periods:
@@ -312,7 +694,7 @@ this option. The password can, still.`)
 // Execute start the web server
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalln(err)
+		tools.Fatal(tools.Logger("server"), "Failed to execute the command", "error", err)
 	}
 }
 
@@ -325,63 +707,154 @@ func welcome() {
 	fmt.Fprint(tools.DefaultGinWriter, "\n\n\n")
 }
 
-func gracefulStart(servers ...*http.Server) {
+// gracefulStart serves the servers, and HTTP/3 unless h3 is nil, until SIGINT or SIGTERM.
+// It returns an error when they are forced to shutdown, it does not exit,
+// so that the deferred closes of the caller, eg: of the download store, still run.
+func gracefulStart(h3 *http3Listener, servers ...*listenServer) error {
 	var err error
 
+	logger := tools.Logger("server")
+
+	app := configs.GetAppConfig()
+
+	opts, err := gnet.ParseSocketOptions(app.SocketMode, app.SocketOwner)
+
+	if err != nil {
+		tools.Fatal(logger, "Invalid socket options", "error", err)
+	}
+
+	// Listening before serving, so that all the addresses fail or none of them
 	for _, server := range servers {
-		// Initializing the server in a goroutine so that
-		// it won't block the graceful shutdown handling below
-		go func(server *http.Server) {
-			if server.TLSConfig != nil {
-				//https
-				tools.DebugPrintF("[INFO] Listening and Serving HTTPS on %s\n", server.Addr)
+		listeners, err := server.listen(opts)
 
-				app := configs.GetAppConfig()
+		if err != nil {
+			tools.Fatal(logger, "Failed to listen", "addr", server.addrs, "error", err)
+		}
 
-				if !app.EnableHTTPS {
-					tools.DebugPrintF("[INFO] Hit CTRL-C to stop the server")
-				}
+		// Serve sets up the TLSConfig of http/2, even for http
+		useTLS := server.TLSConfig != nil
 
-				if err := server.ListenAndServeTLS(app.HTTPSCertFile, app.HTTPSKeyFile); err != nil && err != http.ErrServerClosed {
-					log.Fatalf("listen: %s\n", err)
-				}
-			} else {
-				//http
-				tools.DebugPrintF("[INFO] Listening and Serving HTTP on %s\n", server.Addr)
+		server.track()
 
-				app := configs.GetAppConfig()
+		for _, ln := range listeners {
+			// Initializing the server in a goroutine so that
+			// it won't block the graceful shutdown handling below
+			go func(server *http.Server, ln net.Listener) {
+				if useTLS {
+					//https
+					logger.Info("Listening and Serving HTTPS", "addr", ln.Addr())
 
-				if app.EnableHTTPS {
-					tools.DebugPrintF("[INFO] Hit CTRL-C to stop the server")
+					// The certificates are already in the TLSConfig
+					if err := server.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed {
+						tools.Fatal(logger, "Failed to serve", "addr", ln.Addr(), "error", err)
+					}
+				} else {
+					//http
+					logger.Info("Listening and Serving HTTP", "addr", ln.Addr())
+
+					if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+						tools.Fatal(logger, "Failed to serve", "addr", ln.Addr(), "error", err)
+					}
 				}
+			}(server.Server, ln)
+		}
+	}
+
+	if h3 != nil {
+		conns, err := h3.listen()
 
-				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-					log.Fatalf("listen: %s\n", err)
+		if err != nil {
+			tools.Fatal(logger, "Failed to listen", "addr", h3.addrs, "error", err)
+		}
+
+		for _, conn := range conns {
+			go func(conn net.PacketConn) {
+				logger.Info("Listening and Serving HTTP/3", "addr", conn.LocalAddr())
+
+				if err := h3.Serve(conn); err != nil && err != http.ErrServerClosed {
+					tools.Fatal(logger, "Failed to serve", "addr", conn.LocalAddr(), "error", err)
 				}
-			}
-		}(server)
+			}(conn)
+		}
 	}
 
+	logger.Info("Hit CTRL-C to stop the server")
+
+	// kill -HUP reloads the certificates
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			logger.Info("Reloading the certificates")
+
+			if err := ghttps.ReloadCertificates(); err != nil {
+				logger.Error("Failed to reload the certificates", "error", err)
+			}
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	tools.DebugPrintF("[INFO] Shutting down servers...")
+	logger.Info("Shutting down servers...")
+
+	// The readiness probe fails from now on,
+	// the servers keep serving until the load balancers notice it, or a second signal
+	health.SetShuttingDown()
+
+	if delay := time.Duration(configs.GetHealthConfig().ShutdownDelay) * time.Second; delay > 0 {
+		logger.Info("Waiting for the load balancers", "delay", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-quit:
+		}
+	}
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// HTTP/3 finishes its requests meanwhile, the clients get a GOAWAY
+	http3Done := make(chan error, 1)
+
+	if h3 != nil {
+		go func() {
+			http3Done <- h3.Shutdown(ctx)
+		}()
+	} else {
+		http3Done <- nil
+	}
+
+	var errs []error
+
 	for _, server := range servers {
 		if err = server.Shutdown(ctx); err != nil {
-			log.Fatal("The Web Server forced to shutdown: ", err)
+			// the active connections, eg: the downloads, are closed,
+			// so that their handlers return before the deferred closes of the caller
+			server.Close()
+			server.wait(time.Second)
+
+			errs = append(errs, err)
 		}
 	}
 
-	tools.DebugPrintF("[INFO] The Web Servers have been shut down.")
+	if err = <-http3Done; err != nil {
+		errs = append(errs, fmt.Errorf("http/3: %w", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	logger.Info("The Web Servers have been shut down.")
+
+	return nil
 }
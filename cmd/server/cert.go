@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"snowdream.tech/http-server/pkg/env"
+	gnet "snowdream.tech/http-server/pkg/net"
+	ghttps "snowdream.tech/http-server/pkg/net/https"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+var (
+	certDir  string
+	certName string
+	certDays int
+)
+
+func init() {
+	certCmd.Flags().StringVarP(&certDir, "dir", "", "certs", `The directory of the certificates, the local CA is kept in its ca subdirectory`)
+	certCmd.Flags().StringVarP(&certName, "name", "", "", `The name of the certificate files, <name>.crt and <name>.key.
+If it is empty, the first hostname is used.`)
+	certCmd.Flags().IntVarP(&certDays, "days", "", int(ghttps.ServerValidity/(24*time.Hour)), `The validity of the certificate, in days`)
+
+	rootCmd.AddCommand(certCmd)
+}
+
+var certCmd = &cobra.Command{
+	Use:   "cert [hostname or ip]...",
+	Short: "Issue a server certificate signed by a local CA",
+	Long: `Issue a server certificate for the hostnames and the ips, signed by a local CA.
+The CA is created the first time, add its ca.crt to the trusted certificates of the clients.
+
+If no hostname is given, localhost and the addresses of the network interfaces are used.
+The directory can be used as --tls-certificates-dir of ` + env.ProjectName + `.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := tools.Logger("cert")
+
+		hosts := args
+
+		if len(hosts) == 0 {
+			hosts = ghttps.DefaultHosts(gnet.GetAvailableIPS()...)
+		}
+
+		ca, created, err := ghttps.LoadOrCreateCA(filepath.Join(certDir, "ca"))
+
+		if err != nil {
+			tools.Fatal(logger, "Failed to load the local CA", "dir", filepath.Join(certDir, "ca"), "error", err)
+		}
+
+		if created {
+			fmt.Printf("Created the local CA %s\n", filepath.Join(certDir, "ca", "ca.crt"))
+		}
+
+		pair, err := ghttps.GenerateCertificate(hosts, time.Duration(certDays)*24*time.Hour, ca)
+
+		if err != nil {
+			tools.Fatal(logger, "Failed to issue the certificate", "hosts", hosts, "error", err)
+		}
+
+		name := certName
+
+		if name == "" {
+			// wildcards and ipv6 addresses are not valid file names everywhere
+			name = strings.NewReplacer("*", "_", ":", "_").Replace(hosts[0])
+		}
+
+		certFile, keyFile := filepath.Join(certDir, name+".crt"), filepath.Join(certDir, name+".key")
+
+		if err := pair.Write(certFile, keyFile); err != nil {
+			tools.Fatal(logger, "Failed to write the certificate", "file", certFile, "error", err)
+		}
+
+		fmt.Printf("Issued %s and %s for %s, valid until %s\n", certFile, keyFile, strings.Join(hosts, ", "), pair.Cert.NotAfter.Format(time.RFC3339))
+	},
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+// newHTTP2Server the HTTP/2 settings, every http.Server needs its own
+func newHTTP2Server(app *configs.AppConfig) (*http2.Server, error) {
+	if app.HTTP2MaxStreams < 0 || app.HTTP2MaxStreams > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid http2 max concurrent streams %d", app.HTTP2MaxStreams)
+	}
+
+	// the limits of SETTINGS_MAX_FRAME_SIZE, RFC 9113
+	if app.HTTP2MaxFrameSize != 0 && (app.HTTP2MaxFrameSize < 1<<14 || app.HTTP2MaxFrameSize > 1<<24-1) {
+		return nil, fmt.Errorf("invalid http2 max frame size %d, it should be between 16384 and 16777215", app.HTTP2MaxFrameSize)
+	}
+
+	return &http2.Server{
+		MaxConcurrentStreams: uint32(app.HTTP2MaxStreams),
+		MaxReadFrameSize:     uint32(app.HTTP2MaxFrameSize),
+	}, nil
+}
+
+// configureHTTP2 applies the HTTP/2 settings to the https server,
+// it keeps the HTTP/2 of net/http when none of them is set
+func configureHTTP2(server *http.Server, app *configs.AppConfig) error {
+	h2s, err := newHTTP2Server(app)
+
+	if err != nil {
+		return err
+	}
+
+	if app.HTTP2MaxStreams == 0 && app.HTTP2MaxFrameSize == 0 {
+		return nil
+	}
+
+	return http2.ConfigureServer(server, h2s)
+}
+
+// serveH2C serves HTTP/2 without tls on the http server, with prior knowledge or Upgrade
+func serveH2C(server *http.Server, app *configs.AppConfig) error {
+	h2s, err := newHTTP2Server(app)
+
+	if err != nil {
+		return err
+	}
+
+	// It registers the graceful shutdown of the HTTP/2 connections,
+	// the TLSConfig it sets would make the server listen with tls.
+	if err := http2.ConfigureServer(server, h2s); err != nil {
+		return err
+	}
+
+	server.TLSConfig = nil
+	server.Handler = h2c.NewHandler(server.Handler, h2s)
+
+	return nil
+}
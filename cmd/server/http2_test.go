@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func TestServeH2C(t *testing.T) {
+	app := &configs.AppConfig{HTTP2MaxStreams: 42, HTTP2MaxFrameSize: 1 << 15}
+
+	server := &http.Server{Handler: http.NotFoundHandler()}
+
+	assert.NoError(t, serveH2C(server, app))
+	assert.Nil(t, server.TLSConfig)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go server.Serve(ln)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// prior knowledge
+	_, err = conn.Write([]byte(http2.ClientPreface))
+	assert.NoError(t, err)
+
+	framer := http2.NewFramer(conn, conn)
+	assert.NoError(t, framer.WriteSettings())
+
+	frame, err := framer.ReadFrame()
+	assert.NoError(t, err)
+
+	settings, ok := frame.(*http2.SettingsFrame)
+	assert.True(t, ok)
+
+	streams, ok := settings.Value(http2.SettingMaxConcurrentStreams)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(42), streams)
+
+	size, ok := settings.Value(http2.SettingMaxFrameSize)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1<<15), size)
+}
+
+func TestConfigureHTTP2(t *testing.T) {
+	// net/http serves HTTP/2 by itself
+	server := &http.Server{}
+	assert.NoError(t, configureHTTP2(server, &configs.AppConfig{}))
+	assert.Nil(t, server.TLSNextProto)
+
+	server = &http.Server{}
+	assert.NoError(t, configureHTTP2(server, &configs.AppConfig{HTTP2MaxStreams: 10}))
+	assert.Contains(t, server.TLSNextProto, http2.NextProtoTLS)
+
+	assert.Error(t, configureHTTP2(&http.Server{}, &configs.AppConfig{HTTP2MaxFrameSize: 1024}))
+	assert.Error(t, configureHTTP2(&http.Server{}, &configs.AppConfig{HTTP2MaxStreams: -1}))
+}
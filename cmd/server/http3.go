@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	gnet "snowdream.tech/http-server/pkg/net"
+)
+
+// http3Listener serves the handler over HTTP/3 on the udp ports of https
+type http3Listener struct {
+	*http3.Server
+
+	// addrs the udp addresses, Addr is the first one
+	addrs []string
+}
+
+func newHTTP3Listener(addrs []string, tlsConfig *tls.Config, handler http.Handler) *http3Listener {
+	l := &http3Listener{addrs: addrs}
+
+	l.Server = &http3.Server{
+		Addr:      addrs[0],
+		TLSConfig: tlsConfig,
+		// 0-RTT requests can be replayed, eg: the posts of the admin api
+		QUICConfig:     &quic.Config{Allow0RTT: false},
+		Handler:        handler,
+		MaxHeaderBytes: 1 << 20,
+	}
+
+	// Alt-Svc advertises the port once, not once per address
+	if port := commonPort(addrs); port > 0 {
+		l.Port = port
+	}
+
+	return l
+}
+
+// listen listens on all the udp addresses, or on none of them
+func (l *http3Listener) listen() ([]net.PacketConn, error) {
+	var conns []net.PacketConn
+
+	for _, addr := range l.addrs {
+		conn, err := gnet.ListenPacket(addr)
+
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+
+			return nil, err
+		}
+
+		conns = append(conns, conn)
+	}
+
+	return conns, nil
+}
+
+// AltSvc advertises HTTP/3 on the HTTP/1.1 and HTTP/2 responses
+func (l *http3Listener) AltSvc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor < 3 {
+			// it fails until the server listens
+			l.SetQUICHeaders(w.Header())
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// commonPort the port of all the addresses, 0 if they differ
+func commonPort(addrs []string) int {
+	var port string
+
+	for _, addr := range addrs {
+		_, p, err := net.SplitHostPort(addr)
+
+		if err != nil || (port != "" && p != port) {
+			return 0
+		}
+
+		port = p
+	}
+
+	n, _ := strconv.Atoi(port)
+
+	return n
+}
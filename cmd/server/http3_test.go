@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	ghttps "snowdream.tech/http-server/pkg/net/https"
+)
+
+func TestHTTP3Listener(t *testing.T) {
+	pair, err := ghttps.GenerateCertificate([]string{"127.0.0.1"}, time.Hour, nil)
+	assert.NoError(t, err)
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{pair.TLSCertificate()},
+		MinVersion:   tls.VersionTLS13,
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+
+	// the same port for tcp and udp
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	addr := ln.Addr().String()
+	_, port, _ := net.SplitHostPort(addr)
+
+	h3 := newHTTP3Listener([]string{addr}, tlsConfig, handler)
+
+	conns, err := h3.listen()
+	assert.NoError(t, err)
+
+	go h3.Serve(conns[0])
+
+	server := &http.Server{Handler: h3.AltSvc(handler), TLSConfig: tlsConfig}
+
+	go server.ServeTLS(ln, "", "")
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(pair.Cert)
+
+	// HTTP/1.1 advertises HTTP/3
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	resp, err := client.Get("https://" + addr + "/")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+
+		assert.Equal(t, `h3=":`+port+`"; ma=2592000`, resp.Header.Get("Alt-Svc"))
+	}
+
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+
+	resp, err = (&http.Client{Transport: transport, Timeout: 5 * time.Second}).Get("https://" + addr + "/")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, "HTTP/3.0", string(body))
+		assert.Empty(t, resp.Header.Get("Alt-Svc"))
+	}
+
+	// Shutdown waits for the clients to close their connections
+	transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, h3.Shutdown(ctx))
+}
+
+func TestCommonPort(t *testing.T) {
+	assert.Equal(t, 8443, commonPort([]string{"127.0.0.1:8443", "[::1]:8443"}))
+	assert.Equal(t, 0, commonPort([]string{"127.0.0.1:8443", "[::1]:9443"}))
+}
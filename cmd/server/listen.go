@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	gnet "snowdream.tech/http-server/pkg/net"
+)
+
+// listenServer a server and the addresses it listens on
+type listenServer struct {
+	*http.Server
+
+	// addrs the --listen or --https-listen addresses, Addr otherwise
+	addrs []string
+
+	// active the requests being handled
+	active atomic.Int64
+}
+
+// newListenServer the server listens on the addresses, or on its Addr when there are none
+func newListenServer(server *http.Server, addrs []string) *listenServer {
+	if len(addrs) == 0 {
+		addrs = []string{server.Addr}
+	}
+
+	return &listenServer{Server: server, addrs: addrs}
+}
+
+// tcpAddresses the host:port addresses of the server, eg: for HTTP/3
+func (s *listenServer) tcpAddresses() []string {
+	var addresses []string
+
+	for _, address := range s.addrs {
+		if gnet.IsTCPAddress(address) {
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses
+}
+
+// listen listens on all the addresses of the server, or on none of them
+func (s *listenServer) listen(opts gnet.SocketOptions) ([]net.Listener, error) {
+	var listeners []net.Listener
+
+	for _, address := range s.addrs {
+		list, err := gnet.Listen(address, opts)
+
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+
+			return nil, err
+		}
+
+		listeners = append(listeners, list...)
+	}
+
+	return listeners, nil
+}
+
+// track counts the requests being handled by the server, see wait
+func (s *listenServer) track() {
+	next := s.Handler
+
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+
+	s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.active.Add(1)
+		defer s.active.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wait waits up to d for the requests being handled, eg: after Close
+func (s *listenServer) wait(d time.Duration) bool {
+	deadline := time.Now().Add(d)
+
+	for s.active.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/middlewares"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/metrics"
+	gnet "snowdream.tech/http-server/pkg/net"
+	"snowdream.tech/http-server/pkg/stats"
+)
+
+// newMetricsServer returns the server of the metrics path, or nil if the metrics
+// are disabled or served by the web server
+func newMetricsServer(conf *configs.Configs) *http.Server {
+	metricsConf := configs.GetMetricsConfig()
+
+	if !metricsConf.Enable || metricsConf.Listen == "" {
+		return nil
+	}
+
+	app := configs.GetAppConfig()
+
+	engine := gin.New()
+
+	// validated by the web server
+	engine.SetTrustedProxies(gnet.TrustedProxies(app.TrustedProxies))
+
+	engine.Use(middlewares.Configs(conf))
+	engine.Use(middlewares.RequestID())
+	engine.Use(middlewares.I18N())
+	engine.Use(gin.Recovery())
+
+	engine.GET(metricsConf.Path, middlewares.MetricsHandler())
+
+	return &http.Server{
+		Addr:           metricsConf.Listen,
+		Handler:        engine,
+		ReadTimeout:    time.Duration(app.ReadTimeout) * time.Second,
+		WriteTimeout:   time.Duration(app.WriteTimeout) * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+}
+
+// withMetricsServer appends the server of the metrics to the servers, if any
+func withMetricsServer(conf *configs.Configs, servers ...*listenServer) []*listenServer {
+	for _, server := range servers {
+		server.ConnState = connState
+	}
+
+	if server := newMetricsServer(conf); server != nil {
+		servers = append(servers, newListenServer(server, nil))
+	}
+
+	return servers
+}
+
+// connState counts the connections for the metrics and the admin dashboard
+func connState(conn net.Conn, state http.ConnState) {
+	metrics.ConnState(conn, state)
+	stats.Default().ConnState(conn, state)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/health"
+	ghttps "snowdream.tech/http-server/pkg/net/https"
+)
+
+// clientAuthTypes the values of --https-client-auth
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify":             tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// clientAuthType parses the client certificate policy
+func clientAuthType(s string) (tls.ClientAuthType, error) {
+	clientAuth, ok := clientAuthTypes[s]
+
+	if !ok {
+		return tls.NoClientCert, fmt.Errorf("unknown client auth %q, it should be none, request, require, verify or require-and-verify", s)
+	}
+
+	return clientAuth, nil
+}
+
+// checkClientPaths the paths of --https-client-paths need verified certificates,
+// request and require do not verify them, so that every request of the paths would be refused
+func checkClientPaths(clientAuth tls.ClientAuthType, paths []string) error {
+	if len(paths) > 0 && (clientAuth == tls.RequestClientCert || clientAuth == tls.RequireAnyClientCert) {
+		return errors.New("--https-client-paths needs --https-client-auth verify or require-and-verify, request and require do not verify the certificates")
+	}
+
+	return nil
+}
+
+// loadCertPool loads a PEM bundle of CA certificates
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificate found in " + file)
+	}
+
+	return pool, nil
+}
+
+// certificateLeaves parses the leaf certificates, the invalid ones are skipped
+func certificateLeaves(certs ...tls.Certificate) []*x509.Certificate {
+	leaves := make([]*x509.Certificate, 0, len(certs))
+
+	for _, cert := range certs {
+		if len(cert.Certificate) == 0 {
+			continue
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+
+		if err != nil {
+			continue
+		}
+
+		leaves = append(leaves, leaf)
+	}
+
+	return leaves
+}
+
+// tlsPolicy the preset of the policy, overridden by the versions, the cipher suites and the curves
+func tlsPolicy(app *configs.AppConfig) (ghttps.Policy, error) {
+	policy, err := ghttps.PolicyPreset(app.TLSPolicy)
+
+	if err != nil {
+		return policy, err
+	}
+
+	if app.TLSMinVersion != "" {
+		if policy.MinVersion, err = ghttps.ParseTLSVersion(app.TLSMinVersion); err != nil {
+			return policy, err
+		}
+	}
+
+	if app.TLSMaxVersion != "" {
+		if policy.MaxVersion, err = ghttps.ParseTLSVersion(app.TLSMaxVersion); err != nil {
+			return policy, err
+		}
+	}
+
+	if len(app.TLSCipherSuites) > 0 {
+		if policy.CipherSuites, err = ghttps.ParseCipherSuites(app.TLSCipherSuites); err != nil {
+			return policy, err
+		}
+	}
+
+	if len(app.TLSCurves) > 0 {
+		if policy.CurvePreferences, err = ghttps.ParseCurves(app.TLSCurves); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, policy.Validate()
+}
+
+// alpnProtocols the application protocols offered to the clients, h2 and http/1.1 by default
+func alpnProtocols(app *configs.AppConfig) ([]string, error) {
+	if len(app.TLSALPN) == 0 {
+		return []string{"h2", "http/1.1"}, nil
+	}
+
+	for _, proto := range app.TLSALPN {
+		if proto != "h2" && proto != "http/1.1" {
+			return nil, fmt.Errorf("unsupported alpn protocol %q, it should be h2 or http/1.1", proto)
+		}
+	}
+
+	return app.TLSALPN, nil
+}
+
+// ocspCheck fails when a certificate is revoked, the other stapling problems are warnings,
+// the clients check the revocation themselves.
+func ocspCheck(certs []*ghttps.Certificate) health.Check {
+	return func(ctx context.Context) error {
+		var revoked, warnings []string
+
+		for _, c := range certs {
+			status := c.OCSPStatus()
+
+			switch {
+			case status == nil:
+				continue
+			case status.Status == ghttps.OCSPRevoked:
+				revoked = append(revoked, c.CertFile+" is revoked")
+			case !status.Stapled && status.Err != nil:
+				warnings = append(warnings, fmt.Sprintf("%s is not stapled: %v", c.CertFile, status.Err))
+			case !status.Stapled:
+				warnings = append(warnings, fmt.Sprintf("%s is not stapled, its status is %s", c.CertFile, status.Status))
+			case status.Err != nil:
+				warnings = append(warnings, fmt.Sprintf("%s is stapled until %s, but the refresh failed: %v", c.CertFile, status.NextUpdate.Format(time.RFC3339), status.Err))
+			}
+		}
+
+		if len(revoked) > 0 {
+			return errors.New(strings.Join(revoked, "; "))
+		}
+
+		if len(warnings) > 0 {
+			return health.Warning{Err: errors.New(strings.Join(warnings, "; "))}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAuthType(t *testing.T) {
+	clientAuth, err := clientAuthType("require-and-verify")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, clientAuth)
+
+	_, err = clientAuthType("always")
+	assert.Error(t, err)
+}
+
+func TestCheckClientPaths(t *testing.T) {
+	paths := []string{"/private"}
+
+	assert.Error(t, checkClientPaths(tls.RequestClientCert, paths))
+	assert.Error(t, checkClientPaths(tls.RequireAnyClientCert, paths))
+	assert.NoError(t, checkClientPaths(tls.VerifyClientCertIfGiven, paths))
+	assert.NoError(t, checkClientPaths(tls.RequireAndVerifyClientCert, paths))
+
+	// without paths, no request is refused
+	assert.NoError(t, checkClientPaths(tls.RequestClientCert, nil))
+}
@@ -0,0 +1,190 @@
+package middlewares
+
+import (
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/admin"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// adminCSRFHeader is required on the requests of the admin api which change the state.
+// The html forms cannot set it, and the scripts of other sites need a CORS preflight, which fails.
+const adminCSRFHeader = "X-Requested-With"
+
+// adminRoutes the handlers of the admin api, keyed by "METHOD /path".
+// A path ending with /:id matches the rest of the path, available as c.Param("id").
+type adminRoutes map[string]gin.HandlerFunc
+
+// Admin serves the admin dashboard and its api below the admin prefix,
+// to the admins only.
+func Admin() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Admin")
+
+	conf := configs.GetAdminConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	prefix := adminPrefix(conf)
+
+	dashboard, _ := fs.Sub(admin.GetDashboard(), "dashboard")
+
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(dashboard)))
+
+	routes := adminRoutes{
+		"GET /api/status":               adminGetStatus,
+		"GET /api/stats":                adminGetStats,
+		"GET /api/downloads":            adminGetDownloads,
+		"GET /api/downloads/history":    adminGetHistory,
+		"GET /api/downloads/counts":     adminGetCounts,
+		"DELETE /api/downloads/:id":     adminKickDownload,
+		"GET /api/bans":                 adminGetBans,
+		"POST /api/bans":                adminAddBan,
+		"DELETE /api/bans/:id":          adminRemoveBan,
+		"GET /api/allowlist":            adminGetAllowList,
+		"POST /api/allowlist":           adminAddAllow,
+		"DELETE /api/allowlist/:id":     adminRemoveAllow,
+		"GET /api/shares":               adminGetShares,
+		"POST /api/shares":              adminMintShare,
+		"DELETE /api/shares/:id":        adminRevokeShare,
+		"POST /api/reload/config":       adminReloadConfig,
+		"POST /api/reload/certificates": adminReloadCertificates,
+		"GET /api/maintenance":          adminGetMaintenance,
+		"PUT /api/maintenance":          adminSetMaintenance,
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+
+		if !adminUnderPrefix(path, prefix) {
+			c.Next()
+			return
+		}
+
+		c.Abort()
+
+		if !adminAuthorized(c, conf) {
+			adminUnauthorized(c, conf)
+			return
+		}
+
+		if path == prefix {
+			c.Redirect(http.StatusMovedPermanently, prefix+"/")
+			return
+		}
+
+		rel := strings.TrimPrefix(path, prefix)
+
+		if strings.HasPrefix(rel, "/api/") {
+			if !adminSafeMethod(c.Request.Method) && c.GetHeader(adminCSRFHeader) == "" {
+				adminAbort(c, http.StatusForbidden, ghttp.StatusForbidden, "Forbidden")
+				return
+			}
+
+			if !routes.handle(c, rel) {
+				adminAbort(c, http.StatusNotFound, ghttp.Failure, "Not Found")
+			}
+
+			return
+		}
+
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// adminPrefix the path of the admin dashboard, eg: /admin
+func adminPrefix(conf *configs.AdminConfig) string {
+	return "/" + strings.Trim(conf.Prefix, "/")
+}
+
+// adminUnderPrefix reports whether the path is the admin prefix or below it
+func adminUnderPrefix(path string, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// adminAuthenticates reports whether the request goes to the admin dashboard, which checks
+// the admin credentials itself. The other authentications let such requests through
+// when they fail, they share the Authorization header.
+func adminAuthenticates(conf *configs.AdminConfig, c *gin.Context) bool {
+	return conf.Enable && adminUnderPrefix(c.Request.URL.Path, adminPrefix(conf))
+}
+
+// adminSafeMethod the methods which do not change the state
+func adminSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (routes adminRoutes) handle(c *gin.Context, path string) bool {
+	if handler, ok := routes[c.Request.Method+" "+path]; ok {
+		handler(c)
+		return true
+	}
+
+	// the ids may contain slashes, eg: the CIDRs of the allow list
+	for i := 1; i < len(path)-1; i++ {
+		if path[i] != '/' {
+			continue
+		}
+
+		if handler, ok := routes[c.Request.Method+" "+path[:i]+"/:id"]; ok {
+			c.Params = append(c.Params, gin.Param{Key: "id", Value: path[i+1:]})
+
+			handler(c)
+			return true
+		}
+	}
+
+	return false
+}
+
+// adminAuthorized accepts the users with an admin role, or the admin credentials.
+// Without roles nor credentials, nobody is an admin.
+func adminAuthorized(c *gin.Context, conf *configs.AdminConfig) bool {
+	if user := auth.GetUser(c); user != nil && len(conf.Roles) > 0 && user.HasRole(conf.Roles...) {
+		return true
+	}
+
+	if credentialsAuthorized(c, conf.User, conf.Token) {
+		return true
+	}
+
+	// Only the wrong credentials count as failures, basic auth or bearer tokens,
+	// not the anonymous requests, nor the users authenticated without an admin role.
+	if c.GetHeader("Authorization") != "" && auth.GetUser(c) == nil {
+		if guard := ban.Default(c); guard != nil {
+			guard.Failure(c.Request.Context(), c.ClientIP(), "")
+		}
+	}
+
+	return false
+}
+
+func adminUnauthorized(c *gin.Context, conf *configs.AdminConfig) {
+	if conf.User != "" {
+		c.Header("WWW-Authenticate", "Basic realm="+strconv.Quote("Admin"))
+	} else if conf.Token != "" {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+
+	adminAbort(c, http.StatusUnauthorized, ghttp.StatusUnauthorized, "Unauthorized")
+}
+
+func adminAbort(c *gin.Context, statusCode int, code string, key string) {
+	i18 := i18n.Default(c)
+
+	str := i18.T(c, key)
+
+	ghttp.NegotiateResponse(c, statusCode, ghttp.NewResponse(code, str, nil))
+
+	c.Abort()
+}
@@ -0,0 +1,567 @@
+package middlewares
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/downloads"
+	"snowdream.tech/http-server/pkg/env"
+	"snowdream.tech/http-server/pkg/maintenance"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	ghttps "snowdream.tech/http-server/pkg/net/https"
+	"snowdream.tech/http-server/pkg/share"
+	"snowdream.tech/http-server/pkg/stats"
+)
+
+// adminStats the statistics of the server
+type adminStats struct {
+	Connections int64            `json:"connections" xml:"connections" yaml:"connections"`
+	Downloads   []stats.Download `json:"downloads" xml:"downloads" yaml:"downloads"`
+	TopFiles    []stats.Counter  `json:"topfiles" xml:"topfiles" yaml:"topfiles"`
+	TopClients  []stats.Counter  `json:"topclients" xml:"topclients" yaml:"topclients"`
+	Errors      []stats.Error    `json:"errors" xml:"errors" yaml:"errors"`
+	Limited     []stats.Limited  `json:"limited" xml:"limited" yaml:"limited"`
+}
+
+// adminStatus the live status of the server, shown by the admin dashboard
+type adminStatus struct {
+	Started time.Time         `json:"started" xml:"started" yaml:"started"`
+	Uptime  float64           `json:"uptime" xml:"uptime" yaml:"uptime"`
+	Build   map[string]string `json:"build" xml:"-" yaml:"build"`
+
+	adminStats `yaml:",inline"`
+
+	Bans   []ban.Ban      `json:"bans" xml:"bans" yaml:"bans"`
+	Config map[string]any `json:"config" xml:"-" yaml:"config"`
+}
+
+// adminBan the body of POST /api/bans
+type adminBan struct {
+	Kind  string `json:"kind" binding:"required,oneof=ip user"`
+	Value string `json:"value" binding:"required"`
+
+	// Duration in seconds
+	Duration int64 `json:"duration" binding:"required,gt=0"`
+}
+
+// adminAllow the body of POST /api/allowlist
+type adminAllow struct {
+	Entry string `json:"entry" binding:"required"`
+}
+
+// adminShare the body of POST /api/shares
+type adminShare struct {
+	Path string `json:"path" binding:"required"`
+
+	// TTL in seconds, the default ttl of the share links if it is not set
+	TTL int64 `json:"ttl" binding:"gte=0"`
+}
+
+// adminShareLink a share link, with its path on this server
+type adminShareLink struct {
+	share.Link `yaml:",inline"`
+
+	URL string `json:"url" xml:"url" yaml:"url"`
+}
+
+// adminReloaded the answer of POST /api/reload/config
+type adminReloaded struct {
+	Note string `json:"note" xml:"note" yaml:"note"`
+}
+
+// adminReloadNote the middlewares read their config when the server starts,
+// only the values they read per request change, eg: the admin credentials or the ttl of the share links.
+const adminReloadNote = "The settings read per request have been reloaded. " +
+	"The features, their prefixes, the listeners, the TLS settings, the authentication providers " +
+	"and the limiters are set up at startup, their changes need a restart."
+
+// adminMaintenance the body of PUT /api/maintenance
+type adminMaintenance struct {
+	Enable  bool   `json:"enable"`
+	Message string `json:"message"`
+}
+
+func newAdminStats() adminStats {
+	tracker := stats.Default()
+
+	return adminStats{
+		Connections: tracker.Connections(),
+		Downloads:   tracker.Downloads(),
+		TopFiles:    tracker.TopFiles(10),
+		TopClients:  tracker.TopClients(10),
+		Errors:      tracker.Errors(),
+		Limited:     tracker.LimitedClients(),
+	}
+}
+
+func adminGetStatus(c *gin.Context) {
+	started := stats.Default().Started()
+
+	status := adminStatus{
+		Started:    started,
+		Uptime:     time.Since(started).Seconds(),
+		adminStats: newAdminStats(),
+		Config:     configs.Redact(configs.GetConfigs()),
+		Build: map[string]string{
+			"name":      env.ProjectName,
+			"version":   env.GitTag,
+			"commit":    env.CommitHash,
+			"buildtime": env.BuildTime,
+			"goversion": env.GoVersion,
+			"osarch":    env.OSArch,
+		},
+	}
+
+	if guard := ban.Default(c); guard != nil {
+		status.Bans, _ = guard.Bans(c.Request.Context())
+	}
+
+	adminSuccess(c, status)
+}
+
+func adminGetStats(c *gin.Context) {
+	adminSuccess(c, newAdminStats())
+}
+
+func adminGetDownloads(c *gin.Context) {
+	adminSuccess(c, stats.Default().Downloads())
+}
+
+func adminKickDownload(c *gin.Context) {
+	id := c.Param("id")
+
+	if !stats.Default().Kick(id) {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Not Found")
+		return
+	}
+
+	adminAudit(c, "kick download "+id)
+
+	adminSuccess(c, nil)
+}
+
+// adminGetHistory the last recorded downloads, of the path query if it is set
+func adminGetHistory(c *gin.Context) {
+	store := adminDownloadStore(c)
+
+	if store == nil {
+		return
+	}
+
+	records, err := store.History(c.Request.Context(), c.Query("path"), adminLimit(c))
+
+	if err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminSuccess(c, records)
+}
+
+// adminGetCounts the download counts of the path queries, or of the most downloaded files
+func adminGetCounts(c *gin.Context) {
+	store := adminDownloadStore(c)
+
+	if store == nil {
+		return
+	}
+
+	paths := c.QueryArray("path")
+
+	if len(paths) == 0 {
+		counts, err := store.Top(c.Request.Context(), adminLimit(c))
+
+		if err != nil {
+			adminError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		adminSuccess(c, counts)
+		return
+	}
+
+	found, err := store.Counts(c.Request.Context(), paths...)
+
+	if err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	counts := make([]downloads.Count, 0, len(paths))
+
+	for _, path := range paths {
+		if count, ok := found[path]; ok {
+			counts = append(counts, count)
+		} else {
+			counts = append(counts, downloads.Count{Path: path})
+		}
+	}
+
+	adminSuccess(c, counts)
+}
+
+func adminGetBans(c *gin.Context) {
+	guard := adminGuard(c)
+
+	if guard == nil {
+		return
+	}
+
+	bans, err := guard.Bans(c.Request.Context())
+
+	if err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminSuccess(c, bans)
+}
+
+func adminAddBan(c *gin.Context) {
+	guard := adminGuard(c)
+
+	if guard == nil {
+		return
+	}
+
+	var body adminBan
+
+	if !adminBind(c, &body) {
+		return
+	}
+
+	if err := guard.Ban(c.Request.Context(), body.Kind, body.Value, time.Duration(body.Duration)*time.Second); err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminAudit(c, "ban "+body.Kind+":"+body.Value)
+
+	adminSuccess(c, nil)
+}
+
+// adminRemoveBan the id is kind:value, eg: ip:10.0.0.1 or user:alice
+func adminRemoveBan(c *gin.Context) {
+	guard := adminGuard(c)
+
+	if guard == nil {
+		return
+	}
+
+	kind, value, ok := strings.Cut(c.Param("id"), ":")
+
+	if !ok || (kind != ban.KindIP && kind != ban.KindUser) || value == "" {
+		adminAbort(c, http.StatusBadRequest, ghttp.InvalidParameter, "Invalid Parameter")
+		return
+	}
+
+	if err := guard.Unban(c.Request.Context(), kind, value); err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminAudit(c, "unban "+kind+":"+value)
+
+	adminSuccess(c, nil)
+}
+
+func adminGetAllowList(c *gin.Context) {
+	if guard := adminGuard(c); guard != nil {
+		adminSuccess(c, guard.AllowList())
+	}
+}
+
+func adminAddAllow(c *gin.Context) {
+	guard := adminGuard(c)
+
+	if guard == nil {
+		return
+	}
+
+	var body adminAllow
+
+	if !adminBind(c, &body) {
+		return
+	}
+
+	if !guard.Allow(body.Entry) {
+		adminAbort(c, http.StatusBadRequest, ghttp.InvalidParameter, "Invalid Parameter")
+		return
+	}
+
+	adminAudit(c, "allow "+body.Entry)
+
+	adminSuccess(c, guard.AllowList())
+}
+
+// adminRemoveAllow the id is an ip or a CIDR, eg: 10.0.0.0/8
+func adminRemoveAllow(c *gin.Context) {
+	guard := adminGuard(c)
+
+	if guard == nil {
+		return
+	}
+
+	entry := c.Param("id")
+
+	if !guard.Disallow(entry) {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Not Found")
+		return
+	}
+
+	adminAudit(c, "disallow "+entry)
+
+	adminSuccess(c, guard.AllowList())
+}
+
+func adminGetShares(c *gin.Context) {
+	if conf := adminShareConfig(c); conf != nil {
+		links := share.Default().List()
+
+		list := make([]adminShareLink, 0, len(links))
+
+		for _, link := range links {
+			list = append(list, newAdminShareLink(conf, link))
+		}
+
+		adminSuccess(c, list)
+	}
+}
+
+func adminMintShare(c *gin.Context) {
+	conf := adminShareConfig(c)
+
+	if conf == nil {
+		return
+	}
+
+	var body adminShare
+
+	if !adminBind(c, &body) {
+		return
+	}
+
+	ttl := body.TTL
+
+	if ttl == 0 {
+		ttl = conf.TTL
+	}
+
+	if conf.MaxTTL > 0 && ttl > conf.MaxTTL {
+		adminAbort(c, http.StatusBadRequest, ghttp.InvalidParameter, "Invalid Parameter")
+		return
+	}
+
+	name := path.Clean("/" + body.Path)
+
+	f, err := http.Dir(wwwRoot()).Open(name)
+
+	if err != nil {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Not Found")
+		return
+	}
+
+	d, err := f.Stat()
+	f.Close()
+
+	if err != nil || d.IsDir() {
+		adminAbort(c, http.StatusBadRequest, ghttp.InvalidParameter, "Invalid Parameter")
+		return
+	}
+
+	createdBy := ""
+
+	if user := auth.GetUser(c); user != nil {
+		createdBy = user.Name
+	}
+
+	link, err := share.Default().Mint(name, time.Duration(ttl)*time.Second, createdBy)
+
+	if err != nil {
+		adminError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	adminAudit(c, "share "+name)
+
+	ghttp.NegotiateResponse(c, http.StatusCreated, ghttp.ResponseSuccessWithData(c, newAdminShareLink(conf, link)))
+}
+
+func adminRevokeShare(c *gin.Context) {
+	if adminShareConfig(c) == nil {
+		return
+	}
+
+	if !share.Default().Revoke(c.Param("id")) {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Not Found")
+		return
+	}
+
+	adminAudit(c, "revoke share link")
+
+	adminSuccess(c, nil)
+}
+
+func adminReloadConfig(c *gin.Context) {
+	if err := configs.Reload(); err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminAudit(c, "reload config")
+
+	adminSuccess(c, adminReloaded{Note: adminReloadNote})
+}
+
+func adminReloadCertificates(c *gin.Context) {
+	if err := ghttps.ReloadCertificates(); err != nil {
+		adminError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	adminAudit(c, "reload certificates")
+
+	adminSuccess(c, nil)
+}
+
+func adminGetMaintenance(c *gin.Context) {
+	adminSuccess(c, maintenance.Get())
+}
+
+func adminSetMaintenance(c *gin.Context) {
+	var body adminMaintenance
+
+	if !adminBind(c, &body) {
+		return
+	}
+
+	state := maintenance.Set(body.Enable, body.Message)
+
+	if state.Enable {
+		adminAudit(c, "enable maintenance")
+	} else {
+		adminAudit(c, "disable maintenance")
+	}
+
+	adminSuccess(c, state)
+}
+
+// adminBind binds the json body, the other content types are refused with 415,
+// eg: the html forms posted by other sites with the cached credentials of the admins.
+func adminBind(c *gin.Context, body any) bool {
+	if c.ContentType() != gin.MIMEJSON {
+		adminAbort(c, http.StatusUnsupportedMediaType, ghttp.InvalidParameter, "Unsupported Media Type")
+		return false
+	}
+
+	if err := c.ShouldBindJSON(body); err != nil {
+		adminError(c, http.StatusBadRequest, err)
+		return false
+	}
+
+	return true
+}
+
+// adminGuard returns the Guard of the brute-force protection, or answers 404 if it is disabled
+func adminGuard(c *gin.Context) *ban.Guard {
+	guard := ban.Default(c)
+
+	if guard == nil {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Brute-force protection is disabled")
+	}
+
+	return guard
+}
+
+// adminDownloadStore returns the store of the downloads, or answers 404 if the database is disabled
+func adminDownloadStore(c *gin.Context) *downloads.Store {
+	store := downloads.Default()
+
+	if store == nil {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "The database is disabled")
+	}
+
+	return store
+}
+
+// adminLimit the limit query, 100 by default, at most 1000
+func adminLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+
+	if err != nil || limit <= 0 {
+		return 100
+	}
+
+	return min(limit, 1000)
+}
+
+// adminShareConfig returns the config of the share links, or answers 404 if they are disabled
+func adminShareConfig(c *gin.Context) *configs.ShareConfig {
+	conf := configs.GetShareConfig()
+
+	if !conf.Enable {
+		adminAbort(c, http.StatusNotFound, ghttp.Failure, "Share links are disabled")
+		return nil
+	}
+
+	return conf
+}
+
+func newAdminShareLink(conf *configs.ShareConfig, link share.Link) adminShareLink {
+	return adminShareLink{
+		Link: link,
+		URL:  "/" + strings.Trim(conf.Prefix, "/") + "/" + link.Token,
+	}
+}
+
+func adminSuccess(c *gin.Context, data any) {
+	if data == nil {
+		ghttp.NegotiateResponse(c, http.StatusOK, ghttp.ResponseSuccess(c))
+		return
+	}
+
+	ghttp.NegotiateResponse(c, http.StatusOK, ghttp.ResponseSuccessWithData(c, data))
+}
+
+// adminError answers the error as the message
+func adminError(c *gin.Context, statusCode int, err error) {
+	code := ghttp.Failure
+
+	if statusCode == http.StatusBadRequest {
+		code = ghttp.InvalidParameter
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		c.Error(err)
+	}
+
+	ghttp.NegotiateResponse(c, statusCode, ghttp.NewResponse(code, err.Error(), nil))
+
+	c.Abort()
+}
+
+// adminAudit logs an operation of the admin api in the audit log
+func adminAudit(c *gin.Context, detail string) {
+	event := audit.New(c, audit.ActionAdmin, audit.ResultSuccess)
+	event.Detail = detail
+
+	// the admin credentials of the config
+	if event.User == "" {
+		if user, _, ok := c.Request.BasicAuth(); ok {
+			event.User = user
+			event.Method = auth.MethodBasic
+		} else {
+			event.Method = "token"
+		}
+	}
+
+	audit.Log(event)
+}
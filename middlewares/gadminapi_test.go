@@ -0,0 +1,463 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/maintenance"
+	"snowdream.tech/http-server/pkg/stats"
+)
+
+// adminTestResponse the json body of the admin api
+type adminTestResponse struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// newAdminTestEngine the middlewares of the admin api in front of a file handler,
+// with the token "secret", the basic auth "root:pw", and the user of the X-Test-User header.
+func newAdminTestEngine(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+		maintenance.Set(false, "")
+	})
+
+	conf.App.WwwRoot = t.TempDir()
+	conf.Admin = configs.AdminConfig{Enable: true, Prefix: "/admin", User: "root:pw", Token: "secret", Roles: []string{"admin"}}
+	conf.Share.Enable = true
+	conf.BruteForce.Enable = true
+	conf.BruteForce.Backoff = 0
+
+	assert.NoError(t, os.WriteFile(filepath.Join(conf.App.WwwRoot, "a.txt"), []byte("hello"), 0644))
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(func(c *gin.Context) {
+		if name := c.GetHeader("X-Test-User"); name != "" {
+			auth.SetUser(c, &auth.User{Name: name, Roles: strings.Split(c.GetHeader("X-Test-Roles"), ",")})
+		}
+	})
+	engine.Use(Maintenance())
+	engine.Use(BruteForce())
+	engine.Use(Share())
+	engine.Use(Admin())
+	engine.Use(SharedFile())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	return engine
+}
+
+func adminTestRequest(engine http.Handler, method string, target string, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Accept", gin.MIMEJSON)
+	req.Header.Set(adminCSRFHeader, "XMLHttpRequest")
+
+	if body != "" {
+		req.Header.Set("Content-Type", gin.MIMEJSON)
+	}
+
+	for i := 0; i+1 < len(header); i += 2 {
+		if header[i+1] == "" {
+			req.Header.Del(header[i])
+		} else {
+			req.Header.Set(header[i], header[i+1])
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	return w
+}
+
+func adminTestData(t *testing.T, w *httptest.ResponseRecorder, data any) {
+	var resp adminTestResponse
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NoError(t, json.Unmarshal(resp.Data, data))
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	w := adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="Admin"`, w.Header().Get("WWW-Authenticate"))
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/stats", nil)
+	req.SetBasicAuth("root", "pw")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the roles of an authenticated user
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "", "X-Test-User", "alice", "X-Test-Roles", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "", "X-Test-User", "bob", "X-Test-Roles", "user")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the state-changing endpoints too
+	w = adminTestRequest(engine, http.MethodPut, "/admin/api/maintenance", `{"enable":true}`, "Authorization", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, maintenance.Get().Enable)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin", "")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/admin/", w.Header().Get("Location"))
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/unknown", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/other", "", "Authorization", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "file", w.Body.String())
+}
+
+func TestAdminBehindOtherAuthentications(t *testing.T) {
+	newAdminTestEngine(t)
+
+	conf := configs.GetConfigs()
+	conf.App.Basic = true
+	conf.App.User = "alice:secret"
+	conf.JWT = configs.JWTConfig{Enable: true, Paths: []string{"/admin"}, Algorithms: []string{"HS256"}, Secret: "0123456789abcdef0123456789abcdef", UserClaim: "sub", RolesClaim: "roles"}
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(BruteForce())
+	engine.Use(BasicAuth())
+	engine.Use(JWT())
+	engine.Use(Admin())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	basic := func(target string, user string, password string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.SetBasicAuth(user, password)
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	// the admin credentials of --basic and admin.user
+	assert.Equal(t, http.StatusOK, basic("/admin/api/stats", "root", "pw"))
+	assert.Equal(t, http.StatusUnauthorized, basic("/a.txt", "root", "pw"))
+
+	// the user of --basic is not an admin
+	assert.Equal(t, http.StatusOK, basic("/a.txt", "alice", "secret"))
+	assert.Equal(t, http.StatusUnauthorized, basic("/admin/api/stats", "alice", "secret"))
+
+	// and admin.token
+	w := adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="Admin"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAdminWrongTokens(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	for i := 0; i < 5; i++ {
+		w := adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "", "Authorization", "Bearer guess")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// the client is banned, even with the right token
+	w := adminTestRequest(engine, http.MethodGet, "/admin/api/stats", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestAdminBans(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	w := adminTestRequest(engine, http.MethodPost, "/admin/api/bans", `{"kind":"ip","value":"10.0.0.1","duration":60}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/bans", `{"kind":"user","value":"mallory","duration":60}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/bans", `{"kind":"host","value":"x","duration":60}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/bans", `{"kind":"ip","value":"10.0.0.2"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var bans []map[string]any
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/bans", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	adminTestData(t, w, &bans)
+	assert.Len(t, bans, 2)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/bans/ip:10.0.0.1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/bans/10.0.0.1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/bans", "")
+	adminTestData(t, w, &bans)
+	assert.Len(t, bans, 1)
+	assert.Equal(t, "mallory", bans[0]["value"])
+}
+
+func TestAdminAllowList(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	var list []string
+
+	w := adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", `{"entry":"10.0.0.0/8"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	adminTestData(t, w, &list)
+	assert.Contains(t, list, "10.0.0.0/8")
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", `{"entry":"2001:db8::/32"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", `{"entry":"not an ip"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the ids of the CIDRs contain a slash
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/allowlist/10.0.0.0/8", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	adminTestData(t, w, &list)
+	assert.NotContains(t, list, "10.0.0.0/8")
+	assert.Contains(t, list, "2001:db8::/32")
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/allowlist/2001:db8::/32", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/allowlist/10.0.0.0/8", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/allowlist", "")
+	adminTestData(t, w, &list)
+	assert.NotContains(t, list, "10.0.0.0/8")
+}
+
+func TestAdminShares(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	var link struct {
+		Token string `json:"token"`
+		Path  string `json:"path"`
+		URL   string `json:"url"`
+	}
+
+	w := adminTestRequest(engine, http.MethodPost, "/admin/api/shares", `{"path":"a.txt","ttl":60}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	adminTestData(t, w, &link)
+	assert.Equal(t, "/a.txt", link.Path)
+	assert.Equal(t, "/share/"+link.Token, link.URL)
+
+	// without authentication
+	w = adminTestRequest(engine, http.MethodGet, link.URL, "", "Authorization", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "a.txt")
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/shares", `{"path":"missing.txt"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/shares", `{"path":"a.txt","ttl":99999999}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var links []map[string]any
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/shares", "")
+	adminTestData(t, w, &links)
+	assert.NotEmpty(t, links)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/shares/"+link.Token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/shares/"+link.Token, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, link.URL, "", "Authorization", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminDownloads(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	download := stats.Default().Begin("request", "/a.txt", "10.0.0.1", "", cancel)
+	defer stats.Default().End(download, http.StatusOK, false)
+
+	w := adminTestRequest(engine, http.MethodDelete, "/admin/api/downloads/"+download.ID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, download.Kicked())
+	assert.Error(t, ctx.Err())
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/downloads/request", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// without a database
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/downloads/history", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminMaintenance(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	w := adminTestRequest(engine, http.MethodPut, "/admin/api/maintenance", `{"enable":true,"message":"Upgrading"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/a.txt", "", "Authorization", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Upgrading")
+
+	// the admin prefix stays reachable
+	var state maintenance.State
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/maintenance", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	adminTestData(t, w, &state)
+	assert.True(t, state.Enable)
+
+	w = adminTestRequest(engine, http.MethodPut, "/admin/api/maintenance", `{"enable":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/a.txt", "", "Authorization", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminNegotiation(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	w := adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", `{"entry":"192.0.2.0/24"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/allowlist", "", "Accept", gin.MIMEJSON)
+	assert.Contains(t, w.Header().Get("Content-Type"), gin.MIMEJSON)
+
+	var resp adminTestResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "SUCCESS", resp.Code)
+	assert.Contains(t, string(resp.Data), "192.0.2.0/24")
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/allowlist", "", "Accept", gin.MIMEXML)
+	assert.Contains(t, w.Header().Get("Content-Type"), gin.MIMEXML)
+
+	var xmlResp struct {
+		Code string   `xml:"code"`
+		Data []string `xml:"data"`
+	}
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &xmlResp))
+	assert.Equal(t, "SUCCESS", xmlResp.Code)
+	assert.Contains(t, xmlResp.Data, "192.0.2.0/24")
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/allowlist", "", "Accept", gin.MIMEYAML)
+	assert.Contains(t, w.Header().Get("Content-Type"), gin.MIMEYAML)
+
+	var yamlResp struct {
+		Code string   `yaml:"code"`
+		Data []string `yaml:"data"`
+	}
+	assert.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &yamlResp))
+	assert.Equal(t, "SUCCESS", yamlResp.Code)
+	assert.Contains(t, yamlResp.Data, "192.0.2.0/24")
+
+}
+
+func TestAdminCrossSiteRequests(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	// the html forms
+	w := adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", "entry=0.0.0.0/0", "Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", "<adminAllow><entry>0.0.0.0/0</entry></adminAllow>", "Content-Type", gin.MIMEXML)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPut, "/admin/api/maintenance", "enable=true", "Content-Type", gin.MIMEPlain)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.False(t, maintenance.Get().Enable)
+
+	// without the custom header, even without a body
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/allowlist", `{"entry":"0.0.0.0/0"}`, adminCSRFHeader, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = adminTestRequest(engine, http.MethodPost, "/admin/api/reload/certificates", "", adminCSRFHeader, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = adminTestRequest(engine, http.MethodDelete, "/admin/api/shares/x", "", adminCSRFHeader, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var list []string
+
+	w = adminTestRequest(engine, http.MethodGet, "/admin/api/allowlist", "", adminCSRFHeader, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	adminTestData(t, w, &list)
+	assert.NotContains(t, list, "0.0.0.0/0")
+}
+
+func TestAdminWithoutCors(t *testing.T) {
+	newAdminTestEngine(t)
+
+	cors := gin.New()
+	cors.Use(I18N())
+	cors.Use(Cors())
+	cors.Use(Admin())
+	cors.NoRoute(func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	for _, path := range []string{"/admin/api/stats", "/a.txt"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+		w := httptest.NewRecorder()
+		cors.ServeHTTP(w, req)
+
+		if path == "/a.txt" {
+			assert.Equal(t, "http://localhost:8080", w.Header().Get("Access-Control-Allow-Origin"))
+		} else {
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
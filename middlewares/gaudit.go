@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// AuditDownloads logs the files downloaded by the authenticated users, or by a share link,
+// in the audit log, with the bytes sent.
+func AuditDownloads() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "AuditDownloads")
+
+	if !configs.GetAppConfig().AuditLog {
+		return Empty()
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		link := sharedLink(c)
+
+		if c.Request.Method != http.MethodGet || !strings.HasSuffix(c.FullPath(), "*filepath") || (auth.GetUser(c) == nil && link == nil) {
+			return
+		}
+
+		status := c.Writer.Status()
+
+		// the files, not the directory listings
+		if (status != http.StatusOK && status != http.StatusPartialContent) || strings.HasSuffix(c.Request.URL.Path, "/") {
+			return
+		}
+
+		event := audit.New(c, audit.ActionDownload, audit.ResultSuccess)
+		event.Size = int64(c.Writer.Size())
+
+		if link != nil {
+			event.Path = link.Path
+			event.Detail = "share link"
+		}
+
+		if event.Size < 0 {
+			event.Size = 0
+		}
+
+		audit.Log(event)
+	}
+}
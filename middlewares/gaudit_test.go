@@ -0,0 +1,118 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func TestAuditBasicAuthAndDownloads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	var out bytes.Buffer
+	audit.SetOutput(&out)
+
+	t.Cleanup(func() {
+		*conf = saved
+		audit.SetOutput(io.Discard)
+	})
+
+	conf.App.Basic = true
+	conf.App.User = "alice:secret"
+	conf.App.AuditLog = true
+
+	engine := gin.New()
+
+	engine.Use(BasicAuth())
+	engine.Use(AuditDownloads())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	for _, password := range []string{"wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+		req.SetBasicAuth("alice", password)
+
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var events []audit.Event
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var event audit.Event
+
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, audit.ActionAuthFailure, events[0].Action)
+
+		assert.Equal(t, audit.ActionAuthSuccess, events[1].Action)
+		assert.Equal(t, "alice", events[1].User)
+		assert.Equal(t, "basic", events[1].Method)
+
+		assert.Equal(t, audit.ActionDownload, events[2].Action)
+		assert.Equal(t, "alice", events[2].User)
+		assert.Equal(t, "/a.txt", events[2].Path)
+		assert.Equal(t, int64(5), events[2].Size)
+	}
+}
+
+func TestAuditAuthSuccessOncePerSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	var out bytes.Buffer
+	audit.SetOutput(&out)
+
+	t.Cleanup(func() {
+		*conf = saved
+		audit.SetOutput(io.Discard)
+	})
+
+	conf.App.Basic = true
+	conf.App.User = "alice:secret"
+	conf.App.AuditLog = true
+
+	engine := gin.New()
+
+	engine.Use(BasicAuth())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.1:1235", "192.0.2.1:1236", "192.0.2.2:1234"} {
+		req := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+		req.RemoteAddr = remoteAddr
+		req.SetBasicAuth("alice", "secret")
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// once for each ip
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"ip":"192.0.2.1"`)
+		assert.Contains(t, lines[1], `"ip":"192.0.2.2"`)
+	}
+}
@@ -1,10 +1,17 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/auth/ban"
 	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/metrics"
 	"snowdream.tech/http-server/pkg/tools"
 )
 
@@ -16,7 +23,7 @@ var accounts = gin.Accounts{
 // BasicAuth BasicAuth
 // BasicAuth
 func BasicAuth() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "BasicAuth")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "BasicAuth")
 
 	app := configs.GetAppConfig()
 
@@ -34,5 +41,75 @@ func BasicAuth() gin.HandlerFunc {
 		arr[0]: arr[1],
 	}
 
-	return gin.BasicAuth(accounts)
+	realm := "Basic realm=" + strconv.Quote("Authorization Required")
+
+	admin := configs.GetAdminConfig()
+
+	return func(c *gin.Context) {
+		if sharedLink(c) != nil {
+			c.Next()
+			return
+		}
+
+		user, password, ok := c.Request.BasicAuth()
+
+		guard := ban.Default(c)
+		ctx := c.Request.Context()
+
+		if ok && guard != nil {
+			if wait := guard.Check(ctx, c.ClientIP(), user); wait > 0 {
+				abortBanned(c, wait)
+				return
+			}
+		}
+
+		if !ok || !checkAccount(user, password) {
+			if adminAuthenticates(admin, c) {
+				c.Next()
+				return
+			}
+
+			if ok {
+				if guard != nil {
+					guard.Failure(ctx, c.ClientIP(), user)
+				}
+
+				event := audit.New(c, audit.ActionAuthFailure, audit.ResultFailure)
+				event.User = user
+				event.Method = auth.MethodBasic
+				audit.Log(event)
+
+				metrics.AuthFailure(auth.MethodBasic)
+			}
+
+			c.Header("WWW-Authenticate", realm)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		if guard != nil {
+			guard.Success(ctx, c.ClientIP(), user)
+		}
+
+		event := audit.New(c, audit.ActionAuthSuccess, audit.ResultSuccess)
+		event.User = user
+		event.Method = auth.MethodBasic
+		audit.LogAuthSuccess(event, user+":"+password)
+
+		c.Set(gin.AuthUserKey, user)
+
+		c.Next()
+	}
+}
+
+// checkAccount compares the password in constant time
+func checkAccount(user string, password string) bool {
+	expected, ok := accounts[user]
+
+	if !ok {
+		// compare anyway, so that unknown users take as long as known ones
+		expected = password + "-"
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1 && ok
 }
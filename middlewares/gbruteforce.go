@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	"snowdream.tech/http-server/pkg/metrics"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/stats"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// BruteForce rejects the banned clients, and provides the Guard
+// the authentication middlewares report their failures to.
+func BruteForce() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "BruteForce")
+
+	conf := configs.GetBruteForceConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	var store ban.Store = ban.NewMemoryStore()
+
+	if conf.Store == "redis" {
+		store = ban.NewRedisStore(newRedisClient())
+	}
+
+	guard := ban.NewGuard(store, ban.Options{
+		MaxFailures: conf.MaxFailures,
+		Window:      time.Duration(conf.Window) * time.Second,
+		Backoff:     time.Duration(conf.Backoff) * time.Second,
+		MaxBackoff:  time.Duration(conf.MaxBackoff) * time.Second,
+		BanTime:     time.Duration(conf.BanTime) * time.Second,
+		MaxBanTime:  time.Duration(conf.MaxBanTime) * time.Second,
+		AllowList:   conf.AllowList,
+		UserLockout: conf.UserLockout,
+		OnBan: func(kind, value string, failures int64, d time.Duration) {
+			tools.Logger("bruteforce").Error("Banned after failed logins", "kind", kind, "value", value, "failures", failures, "duration", d)
+
+			event := audit.Event{Action: audit.ActionBan, Result: audit.ResultSuccess, Detail: "banned for " + d.String()}
+
+			if kind == ban.KindIP {
+				event.IP = value
+			} else {
+				event.User = value
+			}
+
+			audit.Log(event)
+		},
+	})
+
+	return func(c *gin.Context) {
+		c.Set(ban.GuardKey, guard)
+
+		if wait := guard.Check(c.Request.Context(), c.ClientIP(), ""); wait > 0 {
+			abortBanned(c, wait)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abortBanned answers 429 with a Retry-After header
+func abortBanned(c *gin.Context, wait time.Duration) {
+	metrics.RateLimited(metrics.LimiterBruteForce)
+	stats.Default().Limit(c.ClientIP(), metrics.LimiterBruteForce)
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	i18 := i18n.Default(c)
+
+	str := i18.T(c, "Too many failed login attempts, Please try again later.")
+
+	ghttp.NegotiateResponse(c, http.StatusTooManyRequests, ghttp.NewResponse(ghttp.TooManyRequests, str, nil))
+
+	c.Abort()
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func newBruteForceTestEngine(t *testing.T, trustedProxies []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+	})
+
+	conf.App.Basic = true
+	conf.App.User = "alice:secret"
+	conf.BruteForce = configs.BruteForceConfig{
+		Enable:      true,
+		Store:       "memory",
+		MaxFailures: 2,
+		Window:      600,
+		BanTime:     900,
+		MaxBanTime:  900,
+		AllowList:   []string{"127.0.0.1", "::1"},
+	}
+
+	engine := gin.New()
+
+	assert.NoError(t, engine.SetTrustedProxies(trustedProxies))
+
+	engine.Use(I18N())
+	engine.Use(BruteForce())
+	engine.Use(BasicAuth())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	return engine
+}
+
+func bruteForceTestRequest(engine http.Handler, forwardedFor string, password string) int {
+	req := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	req.SetBasicAuth("alice", password)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestBruteForceIgnoresForwardedForOfUntrustedPeers(t *testing.T) {
+	engine := newBruteForceTestEngine(t, nil)
+
+	// neither an allow-listed ip nor a new one helps, the peer is banned
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, bruteForceTestRequest(engine, "127.0.0.1", "wrong"))
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, bruteForceTestRequest(engine, "127.0.0.1", "secret"))
+	assert.Equal(t, http.StatusTooManyRequests, bruteForceTestRequest(engine, "203.0.113.3", "secret"))
+}
+
+func TestBruteForceTrustsForwardedForOfTrustedProxies(t *testing.T) {
+	engine := newBruteForceTestEngine(t, []string{"198.51.100.0/24"})
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, bruteForceTestRequest(engine, "203.0.113.1", "wrong"))
+	}
+
+	// the client is banned, not the proxy
+	assert.Equal(t, http.StatusTooManyRequests, bruteForceTestRequest(engine, "203.0.113.1", "secret"))
+	assert.Equal(t, http.StatusOK, bruteForceTestRequest(engine, "203.0.113.2", "secret"))
+}
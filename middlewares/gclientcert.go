@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	"snowdream.tech/http-server/pkg/metrics"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// ClientCert maps the verified TLS client certificate to the user of the request,
+// and requires one below the configured paths.
+func ClientCert() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "ClientCert")
+
+	app := configs.GetAppConfig()
+
+	if !app.EnableHTTPS || app.HTTPSClientAuth == "" || app.HTTPSClientAuth == "none" {
+		return Empty()
+	}
+
+	return func(c *gin.Context) {
+		// Only the chains verified against HTTPSClientCAFile are trusted.
+		if state := c.Request.TLS; state != nil && len(state.VerifiedChains) > 0 {
+			cert := state.VerifiedChains[0][0]
+
+			auth.SetUser(c, auth.CertificateUser(cert))
+
+			audit.LogAuthSuccess(audit.New(c, audit.ActionAuthSuccess, audit.ResultSuccess), string(cert.Raw))
+
+			c.Next()
+			return
+		}
+
+		if len(app.HTTPSClientPaths) > 0 && auth.MatchPath(c.Request.URL.Path, app.HTTPSClientPaths) && sharedLink(c) == nil {
+			event := audit.New(c, audit.ActionAuthFailure, audit.ResultFailure)
+			event.Method = auth.MethodMTLS
+			audit.Log(event)
+
+			metrics.AuthFailure(auth.MethodMTLS)
+
+			i18 := i18n.Default(c)
+
+			str := i18.T(c, "Client certificate required")
+
+			ghttp.NegotiateResponse(c, http.StatusForbidden, ghttp.NewResponse(ghttp.StatusForbidden, str, nil))
+
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
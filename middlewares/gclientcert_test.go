@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func TestClientCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+	})
+
+	conf.App.EnableHTTPS = true
+	conf.App.HTTPSClientAuth = "verify"
+	conf.App.HTTPSClientPaths = []string{"/private"}
+	conf.App.AuditLog = false
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(ClientCert())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		name := "anonymous"
+
+		if user := auth.GetUser(c); user != nil {
+			name = user.Name + ":" + user.Method
+		}
+
+		c.String(http.StatusOK, name)
+	})
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+
+	get := func(path string, state *tls.ConnectionState) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com"+path, nil)
+		req.TLS = state
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// a verified certificate
+	w := get("/private/a.txt", &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice:mtls", w.Body.String())
+
+	// a certificate which is not verified is not trusted
+	w = get("/private/a.txt", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = get("/private/a.txt", &tls.ConnectionState{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the other paths do not need one
+	w = get("/public/a.txt", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "anonymous", w.Body.String())
+}
+
+func TestClientCertDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+	})
+
+	conf.App.EnableHTTPS = true
+	conf.App.HTTPSClientAuth = "none"
+	conf.App.HTTPSClientPaths = []string{"/private"}
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(ClientCert())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private/a.txt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -19,7 +19,7 @@ import (
 // setting explicit values
 // Viper can be thought of as a registry for all of your applications configuration needs.
 func Configs(conf *configs.Configs) gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Configs")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Configs")
 
 	return func(c *gin.Context) {
 		c.Set(configs.ConfigKey, conf)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/configs"
 	"snowdream.tech/http-server/pkg/tools"
 )
 
@@ -19,9 +20,9 @@ var (
 
 // Cors cors
 func Cors() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Cors")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Cors")
 
-	return cors.New(cors.Config{
+	handler := cors.New(cors.Config{
 		AllowOrigins:     []string{},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", " Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"},
@@ -32,4 +33,22 @@ func Cors() gin.HandlerFunc {
 		},
 		MaxAge: 24 * time.Hour,
 	})
+
+	admin := configs.GetAdminConfig()
+
+	if !admin.Enable {
+		return handler
+	}
+
+	prefix := adminPrefix(admin)
+
+	// The admin api is never shared with other origins.
+	return func(c *gin.Context) {
+		if adminUnderPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		handler(c)
+	}
 }
@@ -7,7 +7,7 @@ import (
 
 // Empty Empty
 func Empty() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Empty")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Empty")
 
 	return func(c *gin.Context) {
 		c.Next()
@@ -3,8 +3,10 @@ package middlewares
 import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 	"snowdream.tech/http-server/pkg/configs"
 	"snowdream.tech/http-server/pkg/tools"
+	"snowdream.tech/http-server/pkg/tracing"
 )
 
 var excludedExtentions = []string{
@@ -17,7 +19,7 @@ var excludedExtentions = []string{
 
 // Gzip Gzip
 func Gzip() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Gzip")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Gzip")
 
 	app := configs.GetAppConfig()
 
@@ -25,5 +27,23 @@ func Gzip() gin.HandlerFunc {
 		return Empty()
 	}
 
-	return gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedExtensions(excludedExtentions))
+	handler := gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedExtensions(excludedExtentions))
+
+	if !configs.GetTracingConfig().Enable {
+		return handler
+	}
+
+	return func(c *gin.Context) {
+		ctx, span := tracing.Start(c.Request.Context(), "compression")
+
+		c.Request = c.Request.WithContext(ctx)
+
+		handler(c)
+
+		span.SetAttributes(
+			attribute.String("http.response.header.content-encoding", c.Writer.Header().Get("Content-Encoding")),
+			attribute.Int("http.response.body.size", c.Writer.Size()),
+		)
+		span.End()
+	}
 }
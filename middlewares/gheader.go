@@ -7,7 +7,7 @@ import (
 
 // Header Header
 func Header() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Header")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Header")
 
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("server", "Snowdream HTTP Server/0.1")
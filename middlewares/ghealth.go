@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/health"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// Health serves the liveness and the readiness probes, before any authentication.
+// The readiness checks the wwwroot, and redis when it is used.
+// The results of the checks are only returned with health.details, they are logged otherwise.
+func Health() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Health")
+
+	conf := configs.GetHealthConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	health.Register("wwwroot", func(ctx context.Context) error {
+		return health.CheckDir(configs.GetAppConfig().WwwRoot)
+	})
+
+	if redisRequired() {
+		client := newRedisClient()
+
+		health.Register("redis", func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		})
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.URL.Path {
+		case conf.LivenessPath:
+			ghttp.NegotiateResponse(c, http.StatusOK, ghttp.ResponseSuccess(c))
+		case conf.ReadinessPath:
+			ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(conf.Timeout)*time.Second)
+			defer cancel()
+
+			results, ready := health.Ready(ctx)
+
+			switch {
+			case !ready && conf.Details:
+				ghttp.NegotiateResponse(c, http.StatusServiceUnavailable, ghttp.ResponseFailureWithData(c, results))
+			case !ready:
+				if !health.ShuttingDown() {
+					tools.Logger("middlewares").Warn("The server is not ready", "checks", results)
+				}
+
+				ghttp.NegotiateResponse(c, http.StatusServiceUnavailable, ghttp.ResponseFailure(c))
+			case conf.Details:
+				ghttp.NegotiateResponse(c, http.StatusOK, ghttp.ResponseSuccessWithData(c, results))
+			default:
+				ghttp.NegotiateResponse(c, http.StatusOK, ghttp.ResponseSuccess(c))
+			}
+		default:
+			c.Next()
+			return
+		}
+
+		c.Abort()
+	}
+}
+
+// redisRequired reports whether a feature stores its state in redis only,
+// the rate limiter falls back to memory.
+func redisRequired() bool {
+	bruteForce := configs.GetBruteForceConfig()
+	oidc := configs.GetOIDCConfig()
+
+	return (bruteForce.Enable && bruteForce.Store == "redis") || (oidc.Enable && oidc.SessionStore == "redis")
+}
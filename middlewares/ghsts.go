@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// HSTS sets the Strict-Transport-Security header of the https responses
+func HSTS() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "HSTS")
+
+	app := configs.GetAppConfig()
+
+	if !app.EnableHTTPS || app.HSTSMaxAge <= 0 {
+		return Empty()
+	}
+
+	value := "max-age=" + strconv.FormatInt(app.HSTSMaxAge, 10)
+
+	if app.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	if app.HSTSPreload {
+		// https://hstspreload.org requires both
+		if !app.HSTSIncludeSubdomains || app.HSTSMaxAge < 31536000 {
+			tools.Logger("middlewares").Warn("HSTS preload requires includeSubDomains and a max-age of at least one year")
+		}
+
+		value += "; preload"
+	}
+
+	return func(c *gin.Context) {
+		// Browsers ignore the header over plain http
+		if c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", value)
+		}
+
+		c.Next()
+	}
+}
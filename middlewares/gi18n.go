@@ -9,7 +9,7 @@ import (
 
 // I18N I18N
 func I18N() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "I18N")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "I18N")
 
 	i18ngotext := gotext.NewGotextI18N()
 	// i18n.LoadwithDefaultLanguageFromDisk("./languages/", "zh-Hans")
@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/auth/jwt"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	glog "snowdream.tech/http-server/pkg/log"
+	"snowdream.tech/http-server/pkg/metrics"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// JWT verifies the bearer token of the requests below the configured paths,
+// and maps its claims to the user and the roles of the request.
+func JWT() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "JWT")
+
+	conf := configs.GetJWTConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	verifier, err := jwt.NewVerifier(jwt.Options{
+		Algorithms:  conf.Algorithms,
+		Secret:      []byte(conf.Secret),
+		KeyFile:     conf.KeyFile,
+		JWKSURL:     conf.JWKSURL,
+		JWKSRefresh: time.Duration(conf.JWKSRefresh) * time.Second,
+		Issuer:      conf.Issuer,
+		Audience:    conf.Audience,
+		ClockSkew:   time.Duration(conf.ClockSkew) * time.Second,
+		RequireExp:  conf.RequireExp,
+		HTTPClient:  upstreamClient(),
+	})
+
+	if err != nil {
+		// Fail closed, a broken configuration must not expose the protected paths.
+		tools.Logger("middlewares").Error("JWT is misconfigured, the protected paths are denied", "error", err)
+	}
+
+	admin := configs.GetAdminConfig()
+
+	return func(c *gin.Context) {
+		if !auth.MatchPath(c.Request.URL.Path, conf.Paths) || sharedLink(c) != nil {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+
+		// the admin credentials are not bearer tokens
+		if adminAuthenticates(admin, c) && (verifier == nil || len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ")) {
+			c.Next()
+			return
+		}
+
+		if verifier == nil {
+			jwtUnauthorized(c, "invalid_token")
+			return
+		}
+
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			jwtUnauthorized(c, "")
+			return
+		}
+
+		token := strings.TrimSpace(header[7:])
+
+		claims, err := verifier.Verify(token)
+
+		// eg: the admin token
+		if err != nil && adminAuthenticates(admin, c) {
+			c.Next()
+			return
+		}
+
+		if err != nil {
+			if guard := ban.Default(c); guard != nil {
+				guard.Failure(c.Request.Context(), c.ClientIP(), "")
+			}
+
+			event := audit.New(c, audit.ActionAuthFailure, audit.ResultFailure)
+			event.Method = auth.MethodJWT
+			event.Detail = err.Error()
+			audit.Log(event)
+
+			metrics.AuthFailure(auth.MethodJWT)
+
+			glog.Default(c).Debug("Invalid bearer token", "error", err)
+
+			c.Error(err)
+			jwtUnauthorized(c, "invalid_token")
+			return
+		}
+
+		user := &auth.User{
+			Name:   claims.String(conf.UserClaim),
+			Roles:  auth.MapRoles(claims.Strings(conf.RolesClaim), conf.RoleMap),
+			Method: auth.MethodJWT,
+		}
+
+		if !user.HasRole(conf.Roles...) {
+			if adminAuthenticates(admin, c) {
+				c.Next()
+				return
+			}
+
+			i18 := i18n.Default(c)
+
+			str := i18.T(c, "Forbidden")
+
+			ghttp.NegotiateResponse(c, http.StatusForbidden, ghttp.NewResponse(ghttp.StatusForbidden, str, nil))
+
+			c.Abort()
+			return
+		}
+
+		auth.SetUser(c, user)
+		c.Set(jwt.ClaimsKey, claims)
+
+		audit.LogAuthSuccess(audit.New(c, audit.ActionAuthSuccess, audit.ResultSuccess), token)
+
+		c.Next()
+	}
+}
+
+func jwtUnauthorized(c *gin.Context, code string) {
+	challenge := "Bearer"
+
+	if code != "" {
+		challenge += ` error="` + code + `"`
+	}
+
+	c.Header("WWW-Authenticate", challenge)
+
+	i18 := i18n.Default(c)
+
+	str := i18.T(c, "Unauthorized")
+
+	ghttp.NegotiateResponse(c, http.StatusUnauthorized, ghttp.NewResponse(ghttp.StatusUnauthorized, str, nil))
+
+	c.Abort()
+}
@@ -2,13 +2,13 @@ package middlewares
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
-	libredis "github.com/redis/go-redis/v9"
 	"snowdream.tech/http-server/pkg/configs"
 	"snowdream.tech/http-server/pkg/i18n"
+	"snowdream.tech/http-server/pkg/metrics"
 	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/stats"
 	"snowdream.tech/http-server/pkg/tools"
 
 	limiter "github.com/ulule/limiter/v3"
@@ -19,6 +19,9 @@ import (
 
 // CustomLimitReachedHandler is the Custom LimitReachedHandler used by a new Middleware.
 func CustomLimitReachedHandler(c *gin.Context) {
+	metrics.RateLimited(metrics.LimiterRequests)
+	stats.Default().Limit(c.ClientIP(), metrics.LimiterRequests)
+
 	i18 := i18n.Default(c)
 
 	str := i18.T(c, "Too many requests, Please try again later.")
@@ -35,7 +38,7 @@ func CustomErrorHandler(c *gin.Context, err error) {
 
 // RateLimiter RateLimiter
 func RateLimiter() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "RateLimiter")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "RateLimiter")
 
 	app := configs.GetAppConfig()
 
@@ -64,7 +67,7 @@ func RateLimiter() gin.HandlerFunc {
 	rate, err := limiter.NewRateFromFormatted(app.RateLimiter)
 
 	if err != nil {
-		tools.DebugPrintF(err.Error())
+		tools.Logger("middlewares").Error("Invalid rate limiter", "error", err)
 		return Empty()
 	}
 
@@ -81,20 +84,8 @@ func RateLimiter() gin.HandlerFunc {
 }
 
 func limiterRedisStore() limiter.Store {
-	r := configs.GetRedisConfig()
-
-	// Create a redis client.
-	host := r.Host + ":" + strconv.Itoa(r.Port)
-
-	// Create a redis option.
-	option := &libredis.Options{
-		Addr:     host,
-		Password: r.Password,
-		DB:       1,
-	}
-
 	// Create a redis client.
-	client := libredis.NewClient(option)
+	client := newRedisClient()
 
 	// Create a store with the redis client.
 	store, err := sredis.NewStoreWithOptions(client, limiter.StoreOptions{
@@ -1,51 +1,34 @@
 package middlewares
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"time"
-
 	"github.com/gin-gonic/gin"
-	"gopkg.in/natefinch/lumberjack.v2"
 	"snowdream.tech/http-server/pkg/configs"
+	glog "snowdream.tech/http-server/pkg/log"
 	"snowdream.tech/http-server/pkg/tools"
 )
 
 // LoggerWithFormatter instance a Logger middleware with the specified log format function.
 func LoggerWithFormatter() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Logger")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Logger")
 
 	r := configs.GetAppConfig()
-	logDir := r.LogDir
 
 	// Set access.log
-	accesslog := &lumberjack.Logger{
-		Filename:   logDir + "/access.log",
-		MaxSize:    500, // megabytes
-		MaxBackups: 3,
-		MaxAge:     28,   //days
-		Compress:   true, // disabled by default
+	accesslog, err := glog.OpenStream(configs.GetLogConfig().Access, glog.PriorityInfo)
+
+	if err != nil {
+		tools.Logger("middlewares").Warn("Some access log destinations are not available", "error", err)
 	}
 
-	tools.DefaultAccessWriter = io.MultiWriter(accesslog, os.Stdout)
+	tools.DefaultAccessWriter = accesslog
 
 	// Set access.log middleware
-	accessLogFormatter := func(param gin.LogFormatterParams) string {
-
-		// your custom format
-		return fmt.Sprintf("%s - [%s] %s %s %s %s %d %s \"%s\" %s \n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Request.Header.Get("X-Request-ID"),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
+	accessLogFormatter, err := glog.AccessLogFormatter(r.AccessLogFormat)
+
+	if err != nil {
+		tools.Logger("middlewares").Error("Invalid access log format, the default format is used", "error", err)
+
+		accessLogFormatter, _ = glog.AccessLogFormatter(glog.FormatDefault)
 	}
 
 	accessLogConfig := gin.LoggerConfig{
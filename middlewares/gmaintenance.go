@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	"snowdream.tech/http-server/pkg/maintenance"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// Maintenance answers 503 while the maintenance mode is enabled by an admin.
+// The admin dashboard and its api stay available.
+func Maintenance() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Maintenance")
+
+	admin := configs.GetAdminConfig()
+
+	if !admin.Enable {
+		return Empty()
+	}
+
+	prefix := "/" + strings.Trim(admin.Prefix, "/")
+
+	return func(c *gin.Context) {
+		state := maintenance.Get()
+
+		path := c.Request.URL.Path
+
+		if !state.Enable || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			c.Next()
+			return
+		}
+
+		message := state.Message
+
+		if message == "" {
+			i18 := i18n.Default(c)
+
+			message = i18.T(c, "Service Unavailable")
+		}
+
+		c.Header("Retry-After", "60")
+
+		ghttp.NegotiateResponse(c, http.StatusServiceUnavailable, ghttp.NewResponse(ghttp.Failure, message, nil))
+
+		c.Abort()
+	}
+}
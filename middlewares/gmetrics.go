@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	"snowdream.tech/http-server/pkg/metrics"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+const (
+	// uncompressedSizeKey the size of the response before the compression
+	uncompressedSizeKey = "snowdream.tech/http-server/middlewares/uncompressedsizekey"
+)
+
+// Metrics collects the metrics of the requests, and serves them on the metrics path,
+// unless they are served on a separate listener. It goes after BruteForce,
+// the wrong credentials of the metrics path are failures too.
+func Metrics() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Metrics")
+
+	conf := configs.GetMetricsConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	user, token := conf.User, conf.Token
+	served := conf.Listen == ""
+
+	// The metrics path of the web server must not be public when its files are not.
+	if served && user == "" && token == "" {
+		app := configs.GetAppConfig()
+
+		if app.Basic && app.User != "" {
+			user = app.User
+		} else if configs.GetJWTConfig().Enable || configs.GetOIDCConfig().Enable ||
+			(app.EnableHTTPS && app.HTTPSClientAuth != "" && app.HTTPSClientAuth != "none") {
+			tools.Logger("middlewares").Warn("The metrics are not served by the web server which requires an authentication, set metrics.user, metrics.token or metrics.listen", "path", conf.Path)
+
+			served = false
+		}
+	}
+
+	handler := metricsHandler(user, token)
+
+	return func(c *gin.Context) {
+		if served && c.Request.URL.Path == conf.Path {
+			handler(c)
+			c.Abort()
+			return
+		}
+
+		start := time.Now()
+
+		c.Next()
+
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start), c.Writer.Size())
+
+		if c.Writer.Header().Get("Content-Encoding") != "" {
+			metrics.ObserveCompression(c.GetInt(uncompressedSizeKey), c.Writer.Size())
+		}
+	}
+}
+
+// CompressionMetrics counts the bytes written before the compression, it goes after Gzip.
+func CompressionMetrics() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "CompressionMetrics")
+
+	if !configs.GetMetricsConfig().Enable || !configs.GetAppConfig().Gzip {
+		return Empty()
+	}
+
+	return func(c *gin.Context) {
+		writer := &countingWriter{ResponseWriter: c.Writer}
+
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+
+		c.Set(uncompressedSizeKey, writer.size)
+	}
+}
+
+// MetricsHandler serves the metrics, behind the basic auth or the bearer token of the metrics
+func MetricsHandler() gin.HandlerFunc {
+	conf := configs.GetMetricsConfig()
+
+	return metricsHandler(conf.User, conf.Token)
+}
+
+// metricsHandler serves the metrics, behind the basic auth user:password or the bearer token, if any
+func metricsHandler(user string, token string) gin.HandlerFunc {
+	handler := gin.WrapH(metrics.Handler())
+
+	return func(c *gin.Context) {
+		guard := ban.Default(c)
+		ctx := c.Request.Context()
+		name, _, _ := c.Request.BasicAuth()
+
+		if guard != nil && name != "" {
+			if wait := guard.Check(ctx, c.ClientIP(), name); wait > 0 {
+				abortBanned(c, wait)
+				return
+			}
+		}
+
+		if (user != "" || token != "") && !credentialsAuthorized(c, user, token) {
+			// the credentials may be those of the web server, they are not guessed here either
+			if guard != nil && c.GetHeader("Authorization") != "" {
+				guard.Failure(ctx, c.ClientIP(), name)
+			}
+
+			if user != "" {
+				c.Header("WWW-Authenticate", "Basic realm="+strconv.Quote("Metrics"))
+			} else {
+				c.Header("WWW-Authenticate", "Bearer")
+			}
+
+			i18 := i18n.Default(c)
+
+			str := i18.T(c, "Unauthorized")
+
+			ghttp.NegotiateResponse(c, http.StatusUnauthorized, ghttp.NewResponse(ghttp.StatusUnauthorized, str, nil))
+
+			c.Abort()
+			return
+		}
+
+		if guard != nil && (user != "" || token != "") {
+			guard.Success(ctx, c.ClientIP(), name)
+		}
+
+		handler(c)
+	}
+}
+
+// credentialsAuthorized reports whether the request has the basic auth user:password,
+// or the bearer token. The empty ones are not accepted.
+func credentialsAuthorized(c *gin.Context, userPassword string, token string) bool {
+	if userPassword != "" {
+		if user, password, ok := c.Request.BasicAuth(); ok {
+			return subtle.ConstantTimeCompare([]byte(user+":"+password), []byte(userPassword)) == 1
+		}
+	}
+
+	if token != "" {
+		header := c.GetHeader("Authorization")
+
+		if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+			return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(header[7:])), []byte(token)) == 1
+		}
+	}
+
+	return false
+}
+
+// countingWriter counts the bytes of the body
+type countingWriter struct {
+	gin.ResponseWriter
+	size int
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *countingWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.size += n
+	return n, err
+}
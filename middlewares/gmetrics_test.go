@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func newMetricsTestEngine(t *testing.T, setup func(conf *configs.Configs)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+	})
+
+	conf.Metrics = configs.MetricsConfig{Enable: true, Path: "/metrics"}
+	conf.BruteForce = configs.BruteForceConfig{Enable: true, Store: "memory", MaxFailures: 3, Window: 600, BanTime: 900, MaxBanTime: 900}
+
+	setup(conf)
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(BruteForce())
+	engine.Use(Metrics())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	return engine
+}
+
+func metricsTestRequest(engine http.Handler, user string, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestMetricsPublic(t *testing.T) {
+	engine := newMetricsTestEngine(t, func(conf *configs.Configs) {})
+
+	w := metricsTestRequest(engine, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, "file", w.Body.String())
+}
+
+func TestMetricsBasicAuthOfTheServer(t *testing.T) {
+	engine := newMetricsTestEngine(t, func(conf *configs.Configs) {
+		conf.App.Basic = true
+		conf.App.User = "alice:secret"
+	})
+
+	w := metricsTestRequest(engine, "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = metricsTestRequest(engine, "alice", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = metricsTestRequest(engine, "alice", "secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMetricsOwnCredentials(t *testing.T) {
+	engine := newMetricsTestEngine(t, func(conf *configs.Configs) {
+		conf.App.Basic = true
+		conf.App.User = "alice:secret"
+		conf.Metrics.User = "prometheus:scrape"
+	})
+
+	w := metricsTestRequest(engine, "alice", "secret")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = metricsTestRequest(engine, "prometheus", "scrape")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMetricsNotServedBehindJWT(t *testing.T) {
+	engine := newMetricsTestEngine(t, func(conf *configs.Configs) {
+		conf.JWT.Enable = true
+	})
+
+	// the request goes on to the next handlers
+	w := metricsTestRequest(engine, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "file", w.Body.String())
+}
+
+func TestMetricsWrongCredentialsAreFailures(t *testing.T) {
+	engine := newMetricsTestEngine(t, func(conf *configs.Configs) {
+		conf.App.Basic = true
+		conf.App.User = "alice:secret"
+	})
+
+	for i := 0; i < 3; i++ {
+		w := metricsTestRequest(engine, "alice", "guess")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w := metricsTestRequest(engine, "alice", "secret")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
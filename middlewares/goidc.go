@@ -0,0 +1,305 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/auth/oidc"
+	"snowdream.tech/http-server/pkg/auth/session"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	glog "snowdream.tech/http-server/pkg/log"
+	"snowdream.tech/http-server/pkg/metrics"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+const oidcStateCookie = "http_server_oidc_state"
+
+// oidcLoginState is kept in an encrypted cookie between the login and the callback
+type oidcLoginState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	ReturnTo     string `json:"return_to"`
+}
+
+// OIDC logs the browser in with the OpenID Connect authorization code flow with PKCE,
+// and keeps the user in a session cookie.
+func OIDC() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "OIDC")
+
+	conf := configs.GetOIDCConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	if conf.SessionSecret == "" {
+		tools.Logger("middlewares").Warn("OIDC sessionsecret is empty, the sessions will not survive a restart")
+	}
+
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       conf.Issuer,
+		ClientID:     conf.ClientID,
+		ClientSecret: conf.ClientSecret,
+		Scopes:       conf.Scopes,
+		ClockSkew:    time.Minute,
+		HTTPClient:   upstreamClient(),
+	})
+
+	codec := session.NewCodec(conf.SessionSecret)
+
+	cookie := session.CookieOptions{Name: conf.CookieName, MaxAge: conf.SessionMaxAge}
+
+	var store session.Store = session.NewCookieStore(cookie, codec)
+
+	if conf.SessionStore == "redis" {
+		store = session.NewRedisStore(cookie, newRedisClient())
+	}
+
+	stateCookie := session.CookieOptions{Name: oidcStateCookie, MaxAge: 600}
+
+	admin := configs.GetAdminConfig()
+
+	return func(c *gin.Context) {
+		switch c.Request.URL.Path {
+		case conf.LoginPath:
+			oidcLogin(c, conf, client, codec, stateCookie)
+			return
+		case conf.CallbackPath:
+			oidcCallback(c, conf, client, codec, store, stateCookie)
+			return
+		case conf.LogoutPath:
+			event := audit.New(c, audit.ActionLogout, audit.ResultSuccess)
+			event.Method = auth.MethodOIDC
+
+			if sess, err := store.Load(c); err == nil {
+				event.User = sess.User
+			}
+
+			audit.Log(event)
+
+			store.Delete(c)
+
+			if logoutURL := client.LogoutURL(c.Request.Context(), conf.PostLogoutRedirectURL); logoutURL != "" {
+				c.Redirect(http.StatusFound, logoutURL)
+			} else {
+				c.Redirect(http.StatusFound, "/")
+			}
+
+			c.Abort()
+			return
+		}
+
+		if !auth.MatchPath(c.Request.URL.Path, conf.Paths) || sharedLink(c) != nil {
+			c.Next()
+			return
+		}
+
+		sess, err := store.Load(c)
+
+		// eg: the admin credentials
+		if err != nil && c.GetHeader("Authorization") != "" && adminAuthenticates(admin, c) {
+			c.Next()
+			return
+		}
+
+		if err != nil {
+			// Browsers are sent to the login page, other clients get a 401.
+			if c.Request.Method == http.MethodGet && c.NegotiateFormat(ghttp.OFFEREDALL...) == gin.MIMEHTML {
+				c.Redirect(http.StatusFound, conf.LoginPath+"?return_to="+url.QueryEscape(c.Request.URL.RequestURI()))
+				c.Abort()
+				return
+			}
+
+			oidcAbort(c, http.StatusUnauthorized, ghttp.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		auth.SetUser(c, &auth.User{Name: sess.User, Roles: sess.Roles, Method: auth.MethodOIDC})
+
+		c.Next()
+	}
+}
+
+func oidcLogin(c *gin.Context, conf *configs.OIDCConfig, client *oidc.Client, codec *session.Codec, stateCookie session.CookieOptions) {
+	state := oidcLoginState{
+		State:        oidc.RandomString(),
+		Nonce:        oidc.RandomString(),
+		CodeVerifier: oidc.RandomString(),
+		ReturnTo:     localPath(c.Query("return_to")),
+	}
+
+	start := time.Now()
+
+	authURL, err := client.AuthCodeURL(c.Request.Context(), oidcRedirectURL(c, conf), state.State, state.Nonce, state.CodeVerifier)
+
+	glog.AddUpstreamTime(c, time.Since(start))
+
+	if err != nil {
+		c.Error(err)
+		oidcAbort(c, http.StatusBadGateway, ghttp.Failure, "FAILURE")
+		return
+	}
+
+	value, err := codec.Encode(state)
+
+	if err != nil {
+		c.Error(err)
+		oidcAbort(c, http.StatusInternalServerError, ghttp.Failure, "FAILURE")
+		return
+	}
+
+	session.SetCookie(c, stateCookie, value, stateCookie.MaxAge)
+
+	c.Redirect(http.StatusFound, authURL)
+	c.Abort()
+}
+
+func oidcCallback(c *gin.Context, conf *configs.OIDCConfig, client *oidc.Client, codec *session.Codec, store session.Store, stateCookie session.CookieOptions) {
+	value, _ := c.Cookie(stateCookie.Name)
+
+	// the state cookie is single use
+	session.SetCookie(c, stateCookie, "", -1)
+
+	var state oidcLoginState
+
+	if err := codec.Decode(value, &state); err != nil || state.State == "" || c.Query("state") != state.State {
+		oidcAbort(c, http.StatusBadRequest, ghttp.InvalidParameter, "Invalid login state")
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		oidcFailure(c, errors.New(errCode+": "+c.Query("error_description")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	start := time.Now()
+
+	token, err := client.Exchange(ctx, oidcRedirectURL(c, conf), c.Query("code"), state.CodeVerifier)
+
+	glog.AddUpstreamTime(c, time.Since(start))
+
+	if err != nil {
+		oidcFailure(c, err)
+		return
+	}
+
+	start = time.Now()
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+
+	glog.AddUpstreamTime(c, time.Since(start))
+
+	if err != nil {
+		oidcFailure(c, err)
+		return
+	}
+
+	name := claims.String(conf.UserClaim)
+
+	if name == "" {
+		name = claims.String("sub")
+	}
+
+	user := &auth.User{
+		Name:   name,
+		Roles:  auth.MapRoles(claims.Strings(conf.GroupsClaim), conf.RoleMap),
+		Method: auth.MethodOIDC,
+	}
+
+	if !user.HasRole(conf.Roles...) {
+		event := audit.New(c, audit.ActionLogin, audit.ResultFailure)
+		event.User = user.Name
+		event.Method = auth.MethodOIDC
+		event.Detail = "missing role"
+		audit.Log(event)
+
+		metrics.AuthFailure(auth.MethodOIDC)
+
+		oidcAbort(c, http.StatusForbidden, ghttp.StatusForbidden, "Forbidden")
+		return
+	}
+
+	sess := &session.Session{
+		User:    user.Name,
+		Roles:   user.Roles,
+		Expires: time.Now().Add(time.Duration(conf.SessionMaxAge) * time.Second).Unix(),
+	}
+
+	if err := store.Save(c, sess); err != nil {
+		c.Error(err)
+		oidcAbort(c, http.StatusInternalServerError, ghttp.Failure, "FAILURE")
+		return
+	}
+
+	auth.SetUser(c, user)
+
+	audit.Log(audit.New(c, audit.ActionLogin, audit.ResultSuccess))
+
+	returnTo := state.ReturnTo
+
+	if returnTo == "" {
+		returnTo = "/"
+	}
+
+	c.Redirect(http.StatusFound, returnTo)
+	c.Abort()
+}
+
+func oidcFailure(c *gin.Context, err error) {
+	event := audit.New(c, audit.ActionLogin, audit.ResultFailure)
+	event.Method = auth.MethodOIDC
+	event.Detail = err.Error()
+	audit.Log(event)
+
+	metrics.AuthFailure(auth.MethodOIDC)
+
+	glog.Default(c).Warn("OIDC login failed", "error", err)
+
+	c.Error(err)
+	oidcAbort(c, http.StatusUnauthorized, ghttp.StatusUnauthorized, "Unauthorized")
+}
+
+func oidcAbort(c *gin.Context, statusCode int, code string, key string) {
+	i18 := i18n.Default(c)
+
+	str := i18.T(c, key)
+
+	ghttp.NegotiateResponse(c, statusCode, ghttp.NewResponse(code, str, nil))
+
+	c.Abort()
+}
+
+// oidcRedirectURL the configured redirect url, or the callback path on the current host
+func oidcRedirectURL(c *gin.Context, conf *configs.OIDCConfig) string {
+	if conf.RedirectURL != "" {
+		return conf.RedirectURL
+	}
+
+	scheme := "http"
+
+	if session.IsSecure(c) {
+		scheme = "https"
+	}
+
+	return scheme + "://" + c.Request.Host + conf.CallbackPath
+}
+
+// localPath only keeps paths on this server, so that return_to is not an open redirect
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return ""
+	}
+
+	return p
+}
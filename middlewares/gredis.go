@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"strconv"
+
+	libredis "github.com/redis/go-redis/v9"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+// newRedisClient creates a redis client from RedisConfig
+func newRedisClient() *libredis.Client {
+	r := configs.GetRedisConfig()
+
+	// Create a redis client.
+	host := r.Host + ":" + strconv.Itoa(r.Port)
+
+	// Create a redis option.
+	option := &libredis.Options{
+		Addr:     host,
+		Password: r.Password,
+		DB:       1,
+	}
+
+	return libredis.NewClient(option)
+}
@@ -11,7 +11,7 @@ import (
 
 // Referer Referer
 func Referer() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Referer")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Referer")
 
 	app := configs.GetAppConfig()
 
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/configs"
+	glog "snowdream.tech/http-server/pkg/log"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// maxRequestIDLength the longest request id accepted from the clients
+const maxRequestIDLength = 128
+
+// RequestID accepts or generates the id of every request, echoes it in the response,
+// and attaches it to the logger of the request.
+func RequestID() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "RequestID")
+
+	conf := configs.GetRequestIDConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	header := conf.Header
+
+	if header == "" {
+		header = "X-Request-ID"
+	}
+
+	generator := tools.NewUUID
+
+	if strings.EqualFold(conf.Format, "ulid") {
+		generator = tools.NewULID
+	}
+
+	handler := requestid.New(
+		requestid.WithGenerator(generator),
+		requestid.WithCustomHeaderStrKey(requestid.HeaderStrKey(header)),
+		requestid.WithHandler(func(c *gin.Context, id string) {
+			c.Set(glog.RequestIDKey, id)
+			c.Set(glog.LoggerKey, tools.Logger("request").With("request_id", id))
+		}),
+	)
+
+	return func(c *gin.Context) {
+		// A new id replaces the untrusted or malformed ones, they end up in the logs.
+		if id := c.GetHeader(header); id != "" && (!conf.Trust || !validRequestID(id)) {
+			c.Request.Header.Del(header)
+		}
+
+		handler(c)
+	}
+}
+
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,126 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/auth/ban"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/i18n"
+	ghttp "snowdream.tech/http-server/pkg/net/http"
+	"snowdream.tech/http-server/pkg/share"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+const (
+	// shareLinkKey the share link of the request, see sharedLink
+	shareLinkKey = "snowdream.tech/http-server/middlewares/sharelinkkey"
+)
+
+// Share lets the requests of the share links through the authentications, it goes before them.
+// The files are served by SharedFile, after the stats, the audit and the limiters.
+func Share() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Share")
+
+	conf := configs.GetShareConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	prefix := "/" + strings.Trim(conf.Prefix, "/") + "/"
+
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		link, ok := share.Default().Use(strings.TrimPrefix(c.Request.URL.Path, prefix))
+
+		if !ok {
+			if guard := ban.Default(c); guard != nil {
+				guard.Failure(c.Request.Context(), c.ClientIP(), "")
+			}
+
+			shareNotFound(c)
+			return
+		}
+
+		c.Set(shareLinkKey, &link)
+
+		c.Next()
+	}
+}
+
+// SharedFile serves the file of the share link of the request, see Share.
+func SharedFile() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "SharedFile")
+
+	if !configs.GetShareConfig().Enable {
+		return Empty()
+	}
+
+	return func(c *gin.Context) {
+		link := sharedLink(c)
+
+		if link == nil {
+			c.Next()
+			return
+		}
+
+		c.Abort()
+
+		f, err := http.Dir(wwwRoot()).Open(link.Path)
+
+		if err != nil {
+			shareNotFound(c)
+			return
+		}
+
+		defer f.Close()
+
+		d, err := f.Stat()
+
+		if err != nil || d.IsDir() {
+			shareNotFound(c)
+			return
+		}
+
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.Name()}))
+
+		ghttp.ServeContent(c.Writer, c.Request, d.Name(), d.ModTime(), f)
+	}
+}
+
+// sharedLink the share link of the request, nil if it is not one
+func sharedLink(c *gin.Context) *share.Link {
+	if value, exists := c.Get(shareLinkKey); exists {
+		if link, ok := value.(*share.Link); ok {
+			return link
+		}
+	}
+
+	return nil
+}
+
+// wwwRoot the web root folder
+func wwwRoot() string {
+	if root := configs.GetAppConfig().WwwRoot; root != "" {
+		return root
+	}
+
+	return "."
+}
+
+func shareNotFound(c *gin.Context) {
+	i18 := i18n.Default(c)
+
+	str := i18.T(c, "Not Found")
+
+	ghttp.NegotiateResponse(c, http.StatusNotFound, ghttp.NewResponse(ghttp.Failure, str, nil))
+
+	c.Abort()
+}
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/audit"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/share"
+	"snowdream.tech/http-server/pkg/stats"
+)
+
+func TestShareGoesThroughTheDownloadMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	var out bytes.Buffer
+	audit.SetOutput(&out)
+
+	t.Cleanup(func() {
+		*conf = saved
+		audit.SetOutput(io.Discard)
+	})
+
+	conf.App.WwwRoot = t.TempDir()
+	conf.App.Basic = true
+	conf.App.User = "alice:secret"
+	conf.App.AuditLog = true
+	conf.Admin.Enable = true
+	conf.Share.Enable = true
+
+	assert.NoError(t, os.WriteFile(filepath.Join(conf.App.WwwRoot, "shared.txt"), []byte("hello"), 0644))
+
+	engine := gin.New()
+
+	engine.Use(I18N())
+	engine.Use(Share())
+	engine.Use(BasicAuth())
+	engine.Use(AuditDownloads())
+	engine.Use(Stats())
+	engine.Use(SharedFile())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		c.String(http.StatusOK, "file")
+	})
+
+	link, err := share.Default().Mint("/shared.txt", time.Minute, "")
+	assert.NoError(t, err)
+
+	defer share.Default().Revoke(link.Token)
+
+	// without authentication
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/share/"+link.Token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+
+	// the other files still need it
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shared.txt", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/share/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// counted for the file, not the token
+	found := false
+
+	for _, counter := range stats.Default().TopFiles(0) {
+		if counter.Key == "/shared.txt" {
+			found = true
+			assert.Equal(t, int64(5), counter.Bytes)
+		}
+
+		assert.NotContains(t, counter.Key, link.Token)
+	}
+
+	assert.True(t, found)
+
+	var event audit.Event
+
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(out.String())), &event))
+	assert.Equal(t, audit.ActionDownload, event.Action)
+	assert.Equal(t, "/shared.txt", event.Path)
+	assert.Equal(t, int64(5), event.Size)
+}
@@ -73,7 +73,7 @@ func (mbr *maxBytesReader) Close() error {
 
 // Size Size
 func Size() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "Size")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Size")
 
 	return RequestSizeLimiter(1024 * 1024 * 100)
 }
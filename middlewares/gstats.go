@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/auth"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/downloads"
+	"snowdream.tech/http-server/pkg/stats"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// Stats tracks the downloads, the clients and the errors shown by the admin dashboard,
+// and records the downloads in the database.
+func Stats() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Stats")
+
+	store := downloads.Default()
+
+	if !configs.GetAdminConfig().Enable && store == nil {
+		return Empty()
+	}
+
+	tracker := stats.Default()
+
+	return func(c *gin.Context) {
+		var download *stats.ActiveDownload
+		var writer *downloadWriter
+
+		parent := c.Request.Context()
+
+		if c.Request.Method == http.MethodGet && strings.HasSuffix(c.FullPath(), "*filepath") {
+			ctx, cancel := context.WithCancel(parent)
+			defer cancel()
+
+			c.Request = c.Request.WithContext(ctx)
+
+			user := ""
+
+			if u := auth.GetUser(c); u != nil {
+				user = u.Name
+			}
+
+			path := c.Request.URL.Path
+
+			// the file, not the token
+			if link := sharedLink(c); link != nil {
+				path = link.Path
+			}
+
+			download = tracker.Begin(requestid.Get(c), path, c.ClientIP(), user, cancel)
+
+			writer = &downloadWriter{ResponseWriter: c.Writer, download: download}
+			c.Writer = writer
+		}
+
+		c.Next()
+
+		if download != nil {
+			c.Writer = writer.ResponseWriter
+
+			status := c.Writer.Status()
+
+			// eg: a segment of a parallel download, or a player seeking
+			partial := status == http.StatusPartialContent && !wholeRange(c.Writer.Header().Get("Content-Range"))
+
+			tracker.End(download, status, partial)
+
+			// the files, not the directory listings
+			if store != nil && (status == http.StatusOK || status == http.StatusPartialContent) && !strings.HasSuffix(download.Path, "/") {
+				store.Record(downloads.Record{
+					Path:      download.Path,
+					Client:    download.Client,
+					User:      download.User,
+					Started:   download.Started,
+					Finished:  time.Now(),
+					Bytes:     download.Sent(),
+					Status:    status,
+					Completed: !download.Kicked() && writer.err == nil && parent.Err() == nil,
+					Partial:   partial,
+				})
+			}
+		}
+
+		size := c.Writer.Size()
+
+		if size < 0 {
+			size = 0
+		}
+
+		tracker.Request(c.ClientIP(), int64(size))
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError || len(c.Errors) > 0 {
+			tracker.Error(stats.Error{
+				Status:    status,
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Client:    c.ClientIP(),
+				RequestID: requestid.Get(c),
+				Error:     strings.Join(c.Errors.Errors(), "; "),
+			})
+		}
+	}
+}
+
+// wholeRange reports whether the Content-Range of a partial content covers the whole file.
+// The multipart ranges have none, they are partial.
+func wholeRange(contentRange string) bool {
+	var first, last, size int64
+
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &first, &last, &size); err != nil {
+		return false
+	}
+
+	return first == 0 && last == size-1
+}
+
+// downloadWriter counts the bytes of a download, and fails once it is kicked
+type downloadWriter struct {
+	gin.ResponseWriter
+	download *stats.ActiveDownload
+
+	// err the first failed write, the download is aborted
+	err error
+}
+
+func (w *downloadWriter) Write(data []byte) (int, error) {
+	if w.download.Kicked() {
+		return 0, stats.ErrKicked
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.download.Add(n)
+	w.fail(err)
+	return n, err
+}
+
+func (w *downloadWriter) WriteString(s string) (int, error) {
+	if w.download.Kicked() {
+		return 0, stats.ErrKicked
+	}
+
+	n, err := w.ResponseWriter.WriteString(s)
+	w.download.Add(n)
+	w.fail(err)
+	return n, err
+}
+
+func (w *downloadWriter) fail(err error) {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/stats"
+)
+
+func TestStatsCountsRangesOfTheWholeFileOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := configs.GetConfigs()
+	saved := *conf
+
+	t.Cleanup(func() {
+		*conf = saved
+	})
+
+	conf.Admin.Enable = true
+
+	engine := gin.New()
+
+	engine.Use(Stats())
+
+	engine.GET("/*filepath", func(c *gin.Context) {
+		http.ServeContent(c.Writer, c.Request, "ranges.txt", time.Now(), bytes.NewReader([]byte("0123456789")))
+	})
+
+	requests := func() int64 {
+		for _, counter := range stats.Default().TopFiles(0) {
+			if counter.Key == "/ranges.txt" {
+				return counter.Requests
+			}
+		}
+
+		return 0
+	}
+
+	before := requests()
+
+	for _, r := range []string{"bytes=0-4", "bytes=5-9", "bytes=0-1,5-6", "bytes=0-9", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/ranges.txt", nil)
+
+		if r != "" {
+			req.Header.Set("Range", r)
+		}
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Less(t, w.Code, http.StatusMultipleChoices)
+	}
+
+	// the whole range and the whole file
+	assert.Equal(t, before+2, requests())
+}
+
+func TestWholeRange(t *testing.T) {
+	assert.True(t, wholeRange("bytes 0-9/10"))
+	assert.False(t, wholeRange("bytes 0-4/10"))
+	assert.False(t, wholeRange("bytes 5-9/10"))
+	assert.False(t, wholeRange("bytes */10"))
+	assert.False(t, wholeRange(""))
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"snowdream.tech/http-server/pkg/configs"
+	glog "snowdream.tech/http-server/pkg/log"
+	"snowdream.tech/http-server/pkg/tools"
+	"snowdream.tech/http-server/pkg/tracing"
+)
+
+// Tracing starts a server span for every request, continuing the W3C trace context of the client,
+// and attaches the trace id to the logger of the request.
+func Tracing() gin.HandlerFunc {
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "Tracing")
+
+	conf := configs.GetTracingConfig()
+
+	if !conf.Enable {
+		return Empty()
+	}
+
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		if span.SpanContext().IsValid() {
+			c.Set(glog.LoggerKey, glog.Default(c).With("trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", status),
+			attribute.Int("http.response.body.size", c.Writer.Size()),
+		)
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// upstreamClient the client of the calls to the upstream servers, eg: the JWKS or the OpenID provider
+func upstreamClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
+}
@@ -10,7 +10,7 @@ import (
 
 // XMLHeader XMLHeader
 func XMLHeader() gin.HandlerFunc {
-	tools.DebugPrintF("[INFO] Starting Middleware %s", "XMLHeader")
+	tools.Logger("middlewares").Debug("Starting Middleware", "name", "XMLHeader")
 
 	return func(c *gin.Context) {
 		if c.NegotiateFormat(http.OFFEREDALL...) == gin.MIMEXML {
@@ -0,0 +1,143 @@
+(function () {
+  "use strict";
+
+  var REFRESH = 2000;
+
+  function escape(value) {
+    return String(value === undefined || value === null ? "" : value)
+      .replace(/&/g, "&amp;")
+      .replace(/</g, "&lt;")
+      .replace(/>/g, "&gt;")
+      .replace(/"/g, "&quot;");
+  }
+
+  function size(bytes) {
+    var units = ["B", "KB", "MB", "GB", "TB"];
+    var i = 0;
+
+    while (bytes >= 1024 && i < units.length - 1) {
+      bytes /= 1024;
+      i++;
+    }
+
+    return bytes.toFixed(i === 0 ? 0 : 1) + " " + units[i];
+  }
+
+  function duration(seconds) {
+    seconds = Math.floor(seconds);
+
+    var days = Math.floor(seconds / 86400);
+    var hours = Math.floor((seconds % 86400) / 3600);
+    var minutes = Math.floor((seconds % 3600) / 60);
+
+    return days + "d " + hours + "h " + minutes + "m " + (seconds % 60) + "s";
+  }
+
+  function time(value) {
+    return value ? new Date(value).toLocaleString() : "";
+  }
+
+  // table renders the rows, columns is a list of [title, function(row)]
+  function table(id, columns, rows) {
+    var html = "<tr>";
+
+    columns.forEach(function (column) {
+      html += "<th>" + escape(column[0]) + "</th>";
+    });
+
+    html += "</tr>";
+
+    if (!rows || rows.length === 0) {
+      html += '<tr><td class="empty" colspan="' + columns.length + '">None</td></tr>';
+    } else {
+      rows.forEach(function (row) {
+        html += "<tr>";
+
+        columns.forEach(function (column) {
+          html += "<td>" + escape(column[1](row)) + "</td>";
+        });
+
+        html += "</tr>";
+      });
+    }
+
+    document.getElementById(id).innerHTML = html;
+  }
+
+  function render(status) {
+    var build = status.build || {};
+
+    table("status", [["", function (row) { return row[0]; }], ["", function (row) { return row[1]; }]], [
+      ["Uptime", duration(status.uptime)],
+      ["Started", time(status.started)],
+      ["Version", build.version + " (" + build.commit + ")"],
+      ["Built", build.buildtime + " with " + build.goversion + " for " + build.osarch],
+      ["Connections", status.connections],
+      ["Active downloads", (status.downloads || []).length]
+    ]);
+
+    table("downloads", [
+      ["Path", function (d) { return d.path; }],
+      ["Client", function (d) { return d.client; }],
+      ["User", function (d) { return d.user; }],
+      ["Sent", function (d) { return size(d.bytes); }],
+      ["Speed", function (d) { return size(d.speed) + "/s"; }],
+      ["Started", function (d) { return time(d.started); }]
+    ], status.downloads);
+
+    var counters = [
+      ["Requests", function (c) { return c.requests; }],
+      ["Sent", function (c) { return size(c.bytes); }]
+    ];
+
+    table("files", [["File", function (c) { return c.key; }]].concat(counters), status.topfiles);
+    table("clients", [["Client", function (c) { return c.key; }]].concat(counters), status.topclients);
+
+    table("errors", [
+      ["Time", function (e) { return time(e.time); }],
+      ["Status", function (e) { return e.status; }],
+      ["Request", function (e) { return e.method + " " + e.path; }],
+      ["Client", function (e) { return e.client; }],
+      ["Request ID", function (e) { return e.requestid; }],
+      ["Error", function (e) { return e.error; }]
+    ], status.errors);
+
+    table("limited", [
+      ["Client", function (l) { return l.client; }],
+      ["Limiter", function (l) { return l.limiter; }],
+      ["Count", function (l) { return l.count; }],
+      ["Last", function (l) { return time(l.last); }]
+    ], status.limited);
+
+    table("bans", [
+      ["Kind", function (b) { return b.kind; }],
+      ["Value", function (b) { return b.value; }],
+      ["Until", function (b) { return time(b.until); }]
+    ], status.bans);
+
+    document.getElementById("config").textContent = JSON.stringify(status.config, null, 2);
+    document.getElementById("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+  }
+
+  function refresh() {
+    fetch("api/status", { headers: { Accept: "application/json" }, credentials: "same-origin" })
+      .then(function (response) {
+        if (!response.ok) {
+          throw new Error(response.status + " " + response.statusText);
+        }
+
+        return response.json();
+      })
+      .then(function (response) {
+        render(response.data);
+      })
+      .catch(function (err) {
+        document.getElementById("updated").textContent = "Update failed: " + err.message;
+      })
+      .finally(function () {
+        setTimeout(refresh, REFRESH);
+      });
+  }
+
+  refresh();
+})();
@@ -0,0 +1,13 @@
+package admin
+
+import (
+	"embed"
+)
+
+//go:embed dashboard
+var dashboard embed.FS
+
+// GetDashboard GetDashboard
+func GetDashboard() embed.FS {
+	return dashboard
+}
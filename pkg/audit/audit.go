@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/auth"
+)
+
+// Actions
+const (
+	// ActionLogin a successful login
+	ActionLogin = "login"
+
+	// ActionLogout a logout
+	ActionLogout = "logout"
+
+	// ActionAuthSuccess a successful authentication, eg: basic auth, a bearer token, a client certificate,
+	// logged once per session of the credential
+	ActionAuthSuccess = "auth.success"
+
+	// ActionAuthFailure a failed authentication
+	ActionAuthFailure = "auth.failure"
+
+	// ActionDownload a file downloaded by an authenticated user, Size is the bytes sent
+	ActionDownload = "download"
+
+	// ActionBan an ip or a username has been banned
+	ActionBan = "ban"
+
+	// ActionConfigChange the configuration has been changed
+	ActionConfigChange = "config.change"
+
+	// ActionAdmin an operation of the admin api
+	ActionAdmin = "admin"
+)
+
+// Results
+const (
+	// ResultSuccess ResultSuccess
+	ResultSuccess = "success"
+
+	// ResultFailure ResultFailure
+	ResultFailure = "failure"
+)
+
+// Event one line of the audit log
+type Event struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Result    string    `json:"result"`
+	User      string    `json:"user,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// SessionIdle a credential that has not been used for this long starts a new session,
+// whose first successful authentication is logged again
+const SessionIdle = 30 * time.Minute
+
+// maxSessions bounds the remembered sessions, the idle ones are dropped first
+const maxSessions = 10000
+
+var (
+	mu     sync.Mutex
+	writer io.Writer = io.Discard
+
+	// sessions the last use of the credentials, by the hash of the method, the credential and the ip
+	sessions = map[[sha256.Size]byte]time.Time{}
+)
+
+// SetOutput sets the writer of the audit log, it should be opened in append mode.
+// The sessions are forgotten, so that their next authentication is logged to the new output.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	writer = w
+	sessions = map[[sha256.Size]byte]time.Time{}
+}
+
+// Log writes the event as a json line
+func Log(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	line, err := json.Marshal(event)
+
+	if err != nil {
+		return
+	}
+
+	line = append(line, '\n')
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	writer.Write(line)
+}
+
+// LogAuthSuccess logs a successful authentication once per session,
+// not on every request: the credential, eg: a password or a token, is only logged again
+// from another ip, or after it has been idle for SessionIdle.
+func LogAuthSuccess(event Event, credential string) {
+	key := sha256.Sum256([]byte(event.Method + "\x00" + event.IP + "\x00" + credential))
+	now := time.Now()
+
+	mu.Lock()
+
+	last, ok := sessions[key]
+
+	if len(sessions) >= maxSessions && !ok {
+		for k, t := range sessions {
+			if now.Sub(t) > SessionIdle {
+				delete(sessions, k)
+			}
+		}
+
+		if len(sessions) >= maxSessions {
+			sessions = map[[sha256.Size]byte]time.Time{}
+		}
+	}
+
+	sessions[key] = now
+
+	mu.Unlock()
+
+	if ok && now.Sub(last) <= SessionIdle {
+		return
+	}
+
+	Log(event)
+}
+
+// New returns an event filled with the user, the ip, the request id and the path of the request
+func New(c *gin.Context, action string, result string) Event {
+	event := Event{
+		Action:    action,
+		Result:    result,
+		IP:        c.ClientIP(),
+		RequestID: requestid.Get(c),
+		Path:      c.Request.URL.Path,
+	}
+
+	if user := auth.GetUser(c); user != nil {
+		event.User = user.Name
+		event.Method = user.Method
+	}
+
+	return event
+}
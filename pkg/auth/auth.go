@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserKey UserKey
+	UserKey = "snowdream.tech/http-server/pkg/auth/userkey"
+)
+
+// Authentication methods
+const (
+	// MethodBasic HTTP Basic authentication
+	MethodBasic = "basic"
+
+	// MethodJWT JSON Web Token bearer authentication
+	MethodJWT = "jwt"
+
+	// MethodOIDC OpenID Connect session authentication
+	MethodOIDC = "oidc"
+
+	// MethodMTLS TLS client certificate authentication
+	MethodMTLS = "mtls"
+)
+
+// User is the identity of an authenticated request.
+type User struct {
+	Name   string   `json:"name" xml:"name" yaml:"name"`
+	Roles  []string `json:"roles" xml:"roles" yaml:"roles"`
+	Method string   `json:"method" xml:"method" yaml:"method"`
+}
+
+// HasRole reports whether the user has at least one of the given roles.
+// An empty roles list always matches.
+func (u *User) HasRole(roles ...string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+
+	for _, role := range roles {
+		for _, r := range u.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SetUser stores the user in the context.
+// gin.AuthUserKey is set too, so handlers that only know about
+// gin.BasicAuth keep working.
+func SetUser(c *gin.Context, user *User) {
+	c.Set(UserKey, user)
+	c.Set(gin.AuthUserKey, user.Name)
+}
+
+// GetUser returns the user of the request, or nil if the request is anonymous.
+func GetUser(c *gin.Context) *User {
+	if value, exists := c.Get(UserKey); exists {
+		if user, ok := value.(*User); ok {
+			return user
+		}
+	}
+
+	if name := c.GetString(gin.AuthUserKey); name != "" {
+		return &User{Name: name, Method: MethodBasic}
+	}
+
+	return nil
+}
+
+// MapRoles translates external roles or groups into local roles.
+// Roles without an entry in mapping are kept as they are.
+func MapRoles(roles []string, mapping map[string]string) []string {
+	if len(mapping) == 0 {
+		return roles
+	}
+
+	mapped := make([]string, 0, len(roles))
+
+	for _, role := range roles {
+		// viper lower-cases map keys
+		if local, ok := mapping[strings.ToLower(role)]; ok {
+			mapped = append(mapped, local)
+			continue
+		}
+
+		mapped = append(mapped, role)
+	}
+
+	return mapped
+}
+
+// MatchPath reports whether path is equal to or below one of the prefixes.
+func MatchPath(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == "" || prefix == "/" {
+			return true
+		}
+
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,320 @@
+package ban
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// GuardKey GuardKey
+	GuardKey = "snowdream.tech/http-server/pkg/auth/ban/guardkey"
+)
+
+// Kinds of keys
+const (
+	// KindIP failures and bans of a client ip
+	KindIP = "ip"
+
+	// KindUser failures and bans of a username
+	KindUser = "user"
+)
+
+// Options Options of the Guard
+type Options struct {
+	// MaxFailures within Window before a ban
+	MaxFailures int64
+	Window      time.Duration
+
+	// Backoff is the lock after the first failure, it doubles with every failure,
+	// up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// BanTime is the first ban, it doubles with every ban up to MaxBanTime.
+	BanTime    time.Duration
+	MaxBanTime time.Duration
+
+	// AllowList ips or CIDRs which are never banned
+	AllowList []string
+
+	// UserLockout counts the failures per username too, and locks and bans the username
+	// from every ip. Anyone can then lock a known username out, so it is off by default,
+	// the usernames banned by hand are refused anyway.
+	UserLockout bool
+
+	// OnBan is called when a key is banned
+	OnBan func(kind, value string, failures int64, d time.Duration)
+}
+
+// Ban a banned ip or username
+type Ban struct {
+	Kind  string    `json:"kind" xml:"kind" yaml:"kind"`
+	Value string    `json:"value" xml:"value" yaml:"value"`
+	Until time.Time `json:"until" xml:"until" yaml:"until"`
+}
+
+// Guard tracks the authentication failures per ip, and per username with UserLockout,
+// applies an exponential back-off and bans them, fail2ban style.
+type Guard struct {
+	store Store
+	opts  Options
+
+	mu    sync.RWMutex
+	allow []*net.IPNet
+}
+
+// NewGuard NewGuard
+func NewGuard(store Store, opts Options) *Guard {
+	g := &Guard{store: store, opts: opts}
+
+	for _, entry := range opts.AllowList {
+		g.Allow(entry)
+	}
+
+	return g
+}
+
+// Default returns the Guard of the context, or nil if brute-force protection is disabled
+func Default(c *gin.Context) *Guard {
+	if value, exists := c.Get(GuardKey); exists {
+		if g, ok := value.(*Guard); ok {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// Allow adds an ip or a CIDR to the allow list
+func (g *Guard) Allow(entry string) bool {
+	ipnet := parseAllowEntry(entry)
+
+	if ipnet == nil {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, allowed := range g.allow {
+		if allowed.String() == ipnet.String() {
+			return true
+		}
+	}
+
+	g.allow = append(g.allow, ipnet)
+
+	return true
+}
+
+// Disallow removes an ip or a CIDR from the allow list, it reports whether it was there
+func (g *Guard) Disallow(entry string) bool {
+	ipnet := parseAllowEntry(entry)
+
+	if ipnet == nil {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, allowed := range g.allow {
+		if allowed.String() == ipnet.String() {
+			g.allow = append(g.allow[:i], g.allow[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowList returns the CIDRs of the allow list
+func (g *Guard) AllowList() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	list := make([]string, 0, len(g.allow))
+
+	for _, ipnet := range g.allow {
+		list = append(list, ipnet.String())
+	}
+
+	return list
+}
+
+// Allowed reports whether the ip is in the allow list
+func (g *Guard) Allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+
+	if parsed == nil {
+		return false
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, ipnet := range g.allow {
+		if ipnet.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseAllowEntry parses an ip or a CIDR, nil if it is invalid
+func parseAllowEntry(entry string) *net.IPNet {
+	if !strings.Contains(entry, "/") {
+		if strings.Contains(entry, ":") {
+			entry += "/128"
+		} else {
+			entry += "/32"
+		}
+	}
+
+	_, ipnet, err := net.ParseCIDR(entry)
+
+	if err != nil {
+		return nil
+	}
+
+	return ipnet
+}
+
+// Check returns how long the ip or the username is still locked or banned.
+// The username may be empty.
+func (g *Guard) Check(ctx context.Context, ip string, user string) time.Duration {
+	var wait time.Duration
+
+	for _, key := range g.keys(ip, user) {
+		for _, prefix := range []string{"ban:", "lock:"} {
+			until, err := g.store.Until(ctx, prefix+key)
+
+			if err != nil {
+				continue
+			}
+
+			if d := time.Until(until); d > wait {
+				wait = d
+			}
+		}
+	}
+
+	return wait
+}
+
+// Failure records an authentication failure of the ip and the username.
+// It returns how long they are locked or banned.
+func (g *Guard) Failure(ctx context.Context, ip string, user string) time.Duration {
+	var wait time.Duration
+
+	if !g.opts.UserLockout {
+		user = ""
+	}
+
+	for _, key := range g.keys(ip, user) {
+		failures, err := g.store.Incr(ctx, "fail:"+key, g.opts.Window)
+
+		if err != nil {
+			continue
+		}
+
+		var d time.Duration
+
+		if failures >= g.opts.MaxFailures {
+			bans, _ := g.store.Incr(ctx, "bans:"+key, g.opts.MaxBanTime*2)
+
+			d = exponential(g.opts.BanTime, bans, g.opts.MaxBanTime)
+
+			g.store.SetUntil(ctx, "ban:"+key, time.Now().Add(d))
+			g.store.Delete(ctx, "fail:"+key, "lock:"+key)
+
+			if g.opts.OnBan != nil {
+				kind, value, _ := strings.Cut(key, ":")
+				g.opts.OnBan(kind, value, failures, d)
+			}
+		} else {
+			d = exponential(g.opts.Backoff, failures, g.opts.MaxBackoff)
+
+			g.store.SetUntil(ctx, "lock:"+key, time.Now().Add(d))
+		}
+
+		if d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// Success clears the failures of the ip and the username
+func (g *Guard) Success(ctx context.Context, ip string, user string) {
+	for _, key := range g.keys(ip, user) {
+		g.store.Delete(ctx, "fail:"+key, "lock:"+key)
+	}
+}
+
+// Ban bans an ip or a username for d
+func (g *Guard) Ban(ctx context.Context, kind string, value string, d time.Duration) error {
+	return g.store.SetUntil(ctx, "ban:"+kind+":"+value, time.Now().Add(d))
+}
+
+// Unban lifts the ban, and clears the failures, of an ip or a username
+func (g *Guard) Unban(ctx context.Context, kind string, value string) error {
+	key := kind + ":" + value
+
+	return g.store.Delete(ctx, "ban:"+key, "bans:"+key, "fail:"+key, "lock:"+key)
+}
+
+// Bans lists the current bans
+func (g *Guard) Bans(ctx context.Context) ([]Ban, error) {
+	list, err := g.store.List(ctx, "ban:")
+
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, 0, len(list))
+
+	for key, until := range list {
+		kind, value, _ := strings.Cut(strings.TrimPrefix(key, "ban:"), ":")
+
+		bans = append(bans, Ban{Kind: kind, Value: value, Until: until})
+	}
+
+	return bans, nil
+}
+
+func (g *Guard) keys(ip string, user string) []string {
+	keys := make([]string, 0, 2)
+
+	if ip != "" && !g.Allowed(ip) {
+		keys = append(keys, KindIP+":"+ip)
+	}
+
+	if user != "" {
+		keys = append(keys, KindUser+":"+user)
+	}
+
+	return keys
+}
+
+// exponential returns base * 2^(n-1), capped at max
+func exponential(base time.Duration, n int64, max time.Duration) time.Duration {
+	d := base
+
+	for i := int64(1); i < n && d < max; i++ {
+		d *= 2
+	}
+
+	if max > 0 && d > max {
+		d = max
+	}
+
+	return d
+}
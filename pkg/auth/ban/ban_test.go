@@ -0,0 +1,105 @@
+package ban
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardBackoffAndBan(t *testing.T) {
+	var banned []string
+
+	g := NewGuard(NewMemoryStore(), Options{
+		MaxFailures: 3,
+		Window:      time.Minute,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		BanTime:     time.Hour,
+		MaxBanTime:  24 * time.Hour,
+		UserLockout: true,
+		OnBan: func(kind, value string, failures int64, d time.Duration) {
+			banned = append(banned, kind+":"+value)
+		},
+	})
+
+	ctx := context.Background()
+
+	assert.Equal(t, time.Duration(0), g.Check(ctx, "10.0.0.1", "alice"))
+
+	assert.Equal(t, time.Second, g.Failure(ctx, "10.0.0.1", "alice"))
+	assert.Equal(t, 2*time.Second, g.Failure(ctx, "10.0.0.1", "alice"))
+	assert.Greater(t, g.Check(ctx, "10.0.0.1", ""), time.Duration(0))
+
+	assert.Equal(t, time.Hour, g.Failure(ctx, "10.0.0.1", "alice"))
+	assert.ElementsMatch(t, []string{"ip:10.0.0.1", "user:alice"}, banned)
+
+	// the username is banned from every ip
+	assert.Greater(t, g.Check(ctx, "10.0.0.2", "alice"), 59*time.Minute)
+
+	bans, err := g.Bans(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, bans, 2)
+
+	assert.NoError(t, g.Unban(ctx, KindUser, "alice"))
+	assert.Equal(t, time.Duration(0), g.Check(ctx, "10.0.0.2", "alice"))
+}
+
+func TestGuardWithoutUserLockout(t *testing.T) {
+	g := NewGuard(NewMemoryStore(), Options{
+		MaxFailures: 2,
+		Window:      time.Minute,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		BanTime:     time.Hour,
+		MaxBanTime:  time.Hour,
+	})
+
+	ctx := context.Background()
+
+	g.Failure(ctx, "10.0.0.1", "admin")
+	g.Failure(ctx, "10.0.0.1", "admin")
+
+	// the ip is banned, not the username
+	assert.Greater(t, g.Check(ctx, "10.0.0.1", "admin"), 59*time.Minute)
+	assert.Equal(t, time.Duration(0), g.Check(ctx, "10.0.0.2", "admin"))
+
+	// unless it is banned by hand
+	assert.NoError(t, g.Ban(ctx, KindUser, "admin", time.Hour))
+	assert.Greater(t, g.Check(ctx, "10.0.0.2", "admin"), 59*time.Minute)
+}
+
+func TestGuardAllowList(t *testing.T) {
+	g := NewGuard(NewMemoryStore(), Options{
+		MaxFailures: 1,
+		Window:      time.Minute,
+		BanTime:     time.Hour,
+		MaxBanTime:  time.Hour,
+		AllowList:   []string{"192.168.0.0/16", "::1"},
+	})
+
+	ctx := context.Background()
+
+	g.Failure(ctx, "192.168.1.10", "")
+	g.Failure(ctx, "::1", "")
+
+	assert.Equal(t, time.Duration(0), g.Check(ctx, "192.168.1.10", ""))
+	assert.Equal(t, time.Duration(0), g.Check(ctx, "::1", ""))
+
+	assert.Equal(t, []string{"192.168.0.0/16", "::1/128"}, g.AllowList())
+
+	assert.True(t, g.Allow("10.0.0.1"))
+	assert.False(t, g.Allow("not an ip"))
+	assert.True(t, g.Allowed("10.0.0.1"))
+
+	assert.True(t, g.Disallow("10.0.0.1/32"))
+	assert.False(t, g.Disallow("10.0.0.1"))
+	assert.False(t, g.Allowed("10.0.0.1"))
+}
+
+func TestExponential(t *testing.T) {
+	assert.Equal(t, time.Second, exponential(time.Second, 1, time.Minute))
+	assert.Equal(t, 8*time.Second, exponential(time.Second, 4, time.Minute))
+	assert.Equal(t, time.Minute, exponential(time.Second, 20, time.Minute))
+}
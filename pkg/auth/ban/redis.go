@@ -0,0 +1,96 @@
+package ban
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	libredis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore a Store shared by all the replicas
+type RedisStore struct {
+	client *libredis.Client
+	prefix string
+}
+
+// NewRedisStore NewRedisStore
+func NewRedisStore(client *libredis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "BruteForce:"}
+}
+
+// Incr Incr
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := s.client.TxPipeline()
+
+	incr := pipe.Incr(ctx, s.prefix+key)
+	pipe.ExpireNX(ctx, s.prefix+key, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// SetUntil SetUntil
+func (s *RedisStore) SetUntil(ctx context.Context, key string, until time.Time) error {
+	ttl := time.Until(until)
+
+	if ttl <= 0 {
+		return s.Delete(ctx, key)
+	}
+
+	return s.client.Set(ctx, s.prefix+key, until.Unix(), ttl).Err()
+}
+
+// Until Until
+func (s *RedisStore) Until(ctx context.Context, key string) (time.Time, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Int64()
+
+	if err == libredis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(value, 0), nil
+}
+
+// Delete Delete
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		prefixed = append(prefixed, s.prefix+key)
+	}
+
+	return s.client.Del(ctx, prefixed...).Err()
+}
+
+// List List
+func (s *RedisStore) List(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	list := map[string]time.Time{}
+
+	iter := s.client.Scan(ctx, 0, s.prefix+prefix+"*", 100).Iterator()
+
+	for iter.Next(ctx) {
+		value, err := s.client.Get(ctx, iter.Val()).Result()
+
+		if err != nil {
+			continue
+		}
+
+		until, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		list[iter.Val()[len(s.prefix):]] = time.Unix(until, 0)
+	}
+
+	return list, iter.Err()
+}
@@ -0,0 +1,136 @@
+package ban
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps the failure counters and the bans.
+// MemoryStore is used by a single instance, RedisStore shares the state between replicas.
+type Store interface {
+	// Incr increments the counter of key, which expires ttl after the first increment.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// SetUntil sets key until the given time.
+	SetUntil(ctx context.Context, key string, until time.Time) error
+
+	// Until returns the time key was set until, or the zero time.
+	Until(ctx context.Context, key string) (time.Time, error)
+
+	// Delete deletes the keys.
+	Delete(ctx context.Context, keys ...string) error
+
+	// List returns the keys starting with prefix which are set until a time in the future.
+	List(ctx context.Context, prefix string) (map[string]time.Time, error)
+}
+
+type memoryEntry struct {
+	count   int64
+	until   time.Time
+	expires time.Time
+}
+
+// MemoryStore an in-memory Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	cleaned time.Time
+}
+
+// NewMemoryStore NewMemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*memoryEntry{}}
+}
+
+// get returns the entry of key, expired entries are removed on the way
+func (s *MemoryStore) get(key string, now time.Time) *memoryEntry {
+	if now.Sub(s.cleaned) > time.Minute {
+		s.cleaned = now
+
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	e, ok := s.entries[key]
+
+	if !ok || now.After(e.expires) {
+		return nil
+	}
+
+	return e
+}
+
+// Incr Incr
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	e := s.get(key, now)
+
+	if e == nil {
+		e = &memoryEntry{expires: now.Add(ttl)}
+		s.entries[key] = e
+	}
+
+	e.count++
+
+	return e.count, nil
+}
+
+// SetUntil SetUntil
+func (s *MemoryStore) SetUntil(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &memoryEntry{until: until, expires: until}
+
+	return nil
+}
+
+// Until Until
+func (s *MemoryStore) Until(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.get(key, time.Now()); e != nil {
+		return e.until, nil
+	}
+
+	return time.Time{}, nil
+}
+
+// Delete Delete
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// List List
+func (s *MemoryStore) List(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	list := map[string]time.Time{}
+
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) && e.until.After(now) {
+			list[key] = e.until
+		}
+	}
+
+	return list, nil
+}
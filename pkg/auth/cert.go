@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/x509"
+)
+
+// CertificateUser maps a verified client certificate to a user.
+// The name is the subject common name, or else the first email, DNS or URI SAN.
+// The organizational units of the subject are the roles.
+func CertificateUser(cert *x509.Certificate) *User {
+	name := cert.Subject.CommonName
+
+	if name == "" && len(cert.EmailAddresses) > 0 {
+		name = cert.EmailAddresses[0]
+	}
+
+	if name == "" && len(cert.DNSNames) > 0 {
+		name = cert.DNSNames[0]
+	}
+
+	if name == "" && len(cert.URIs) > 0 {
+		name = cert.URIs[0].String()
+	}
+
+	return &User{
+		Name:   name,
+		Roles:  cert.Subject.OrganizationalUnit,
+		Method: MethodMTLS,
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateUser(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"admin", "ops"}},
+	}
+
+	user := CertificateUser(cert)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, []string{"admin", "ops"}, user.Roles)
+	assert.Equal(t, MethodMTLS, user.Method)
+
+	// the SANs, without a common name
+	assert.Equal(t, "bob@example.com", CertificateUser(&x509.Certificate{EmailAddresses: []string{"bob@example.com"}, DNSNames: []string{"b.example.com"}}).Name)
+	assert.Equal(t, "b.example.com", CertificateUser(&x509.Certificate{DNSNames: []string{"b.example.com"}}).Name)
+
+	uri, _ := url.Parse("spiffe://example.com/bob")
+	assert.Equal(t, "spiffe://example.com/bob", CertificateUser(&x509.Certificate{URIs: []*url.URL{uri}}).Name)
+
+	assert.Empty(t, CertificateUser(&x509.Certificate{}).Roles)
+}
@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Claims the claims set of a JSON Web Token
+type Claims map[string]any
+
+// Lookup returns the value of the claim.
+// Nested claims can be reached with a dotted path, eg: "realm_access.roles".
+func (c Claims) Lookup(name string) (any, bool) {
+	if value, ok := c[name]; ok {
+		return value, true
+	}
+
+	var current any = map[string]any(c)
+
+	for _, part := range strings.Split(name, ".") {
+		object, ok := current.(map[string]any)
+
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = object[part]
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// String returns the claim as a string.
+func (c Claims) String(name string) string {
+	value, ok := c.Lookup(name)
+
+	if !ok {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	return ""
+}
+
+// Strings returns the claim as a list of strings.
+// A single string is split on spaces, like the scope claim.
+func (c Claims) Strings(name string) []string {
+	value, ok := c.Lookup(name)
+
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	}
+
+	return nil
+}
+
+// Time returns a NumericDate claim such as exp, nbf or iat.
+func (c Claims) Time(name string) (time.Time, bool) {
+	value, ok := c[name]
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var seconds float64
+
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		seconds = f
+	case float64:
+		seconds = v
+	default:
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
@@ -0,0 +1,412 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ClaimsKey ClaimsKey
+	ClaimsKey = "snowdream.tech/http-server/pkg/auth/jwt/claimskey"
+)
+
+// Errors returned by Verify
+var (
+	// ErrMalformed the token is not a compact JWS
+	ErrMalformed = errors.New("jwt: malformed token")
+
+	// ErrAlgorithm the signing algorithm is not allowed
+	ErrAlgorithm = errors.New("jwt: algorithm not allowed")
+
+	// ErrSignature no key verified the signature
+	ErrSignature = errors.New("jwt: invalid signature")
+
+	// ErrExpired the token is expired
+	ErrExpired = errors.New("jwt: token is expired")
+
+	// ErrNoExpiry the token has no exp claim, and RequireExp is set
+	ErrNoExpiry = errors.New("jwt: token has no expiration")
+
+	// ErrNotValidYet the token is not valid yet
+	ErrNotValidYet = errors.New("jwt: token is not valid yet")
+
+	// ErrIssuer the issuer does not match
+	ErrIssuer = errors.New("jwt: invalid issuer")
+
+	// ErrAudience the audience does not match
+	ErrAudience = errors.New("jwt: invalid audience")
+)
+
+// DefaultAlgorithms all the algorithms supported by the Verifier
+var DefaultAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// Options Options used to build a Verifier
+type Options struct {
+	// Algorithms allowed. If empty, DefaultAlgorithms is used.
+	Algorithms []string
+
+	// Secret for HS256/HS384/HS512.
+	Secret []byte
+
+	// KeyFile is a PEM public key, certificate or a JWKS json file.
+	// It is reloaded when it changes on disk.
+	KeyFile string
+
+	// JWKSURL is fetched and cached for JWKSRefresh.
+	// Unknown key ids trigger a refetch, so that key rotation is picked up.
+	JWKSURL     string
+	JWKSRefresh time.Duration
+
+	// Issuer expected in the iss claim, if not empty.
+	Issuer string
+
+	// Audience, at least one of them must be in the aud claim, if not empty.
+	Audience []string
+
+	// ClockSkew tolerated when checking exp, nbf and iat.
+	ClockSkew time.Duration
+
+	// RequireExp refuses the tokens without an exp claim, they would be valid forever.
+	RequireExp bool
+
+	// HTTPClient used to fetch JWKSURL. Defaults to a client with a 10 seconds timeout.
+	HTTPClient *http.Client
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verifier verifies compact serialized JSON Web Tokens.
+type Verifier struct {
+	opts       Options
+	algorithms map[string]bool
+	sources    []KeySource
+}
+
+// NewVerifier NewVerifier
+func NewVerifier(opts Options) (*Verifier, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	algorithms := opts.Algorithms
+
+	if len(algorithms) == 0 {
+		algorithms = DefaultAlgorithms
+	}
+
+	v := &Verifier{
+		opts:       opts,
+		algorithms: make(map[string]bool, len(algorithms)),
+	}
+
+	for _, alg := range algorithms {
+		if _, ok := hashes[alg]; !ok {
+			return nil, fmt.Errorf("jwt: unsupported algorithm %q", alg)
+		}
+
+		v.algorithms[alg] = true
+	}
+
+	if len(opts.Secret) > 0 {
+		v.sources = append(v.sources, StaticKeySet{{Key: opts.Secret}})
+	}
+
+	if opts.KeyFile != "" {
+		source, err := NewFileKeySet(opts.KeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v.sources = append(v.sources, source)
+	}
+
+	if opts.JWKSURL != "" {
+		v.sources = append(v.sources, NewRemoteKeySet(opts.JWKSURL, opts.HTTPClient, opts.JWKSRefresh))
+	}
+
+	if len(v.sources) == 0 {
+		return nil, errors.New("jwt: one of secret, key file or jwks url is required")
+	}
+
+	return v, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks the signature and the registered claims of the token,
+// and returns its claims.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	var h header
+
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return nil, ErrMalformed
+	}
+
+	if !v.algorithms[h.Alg] {
+		return nil, ErrAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+
+	if err := v.verifySignature(h, signingInput, signature); err != nil {
+		return nil, err
+	}
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	claims := Claims{}
+
+	decoder := json.NewDecoder(bytes.NewReader(rawClaims))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, ErrMalformed
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(h header, signingInput []byte, signature []byte) error {
+	for _, source := range v.sources {
+		keys, err := source.Keys(h.Kid)
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if key.Algorithm != "" && key.Algorithm != h.Alg {
+				continue
+			}
+
+			if verify(h.Alg, key.Key, signingInput, signature) == nil {
+				return nil
+			}
+		}
+	}
+
+	return ErrSignature
+}
+
+func (v *Verifier) validate(claims Claims) error {
+	now := v.opts.Now()
+	skew := v.opts.ClockSkew
+
+	// the registered time claims are NumericDates, or the checks below would be skipped
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if _, exists := claims[name]; exists {
+			if _, ok := claims.Time(name); !ok {
+				return ErrMalformed
+			}
+		}
+	}
+
+	if _, exists := claims["exp"]; !exists && v.opts.RequireExp {
+		return ErrNoExpiry
+	}
+
+	if exp, ok := claims.Time("exp"); ok && !now.Before(exp.Add(skew)) {
+		return ErrExpired
+	}
+
+	if nbf, ok := claims.Time("nbf"); ok && now.Add(skew).Before(nbf) {
+		return ErrNotValidYet
+	}
+
+	if iat, ok := claims.Time("iat"); ok && now.Add(skew).Before(iat) {
+		return ErrNotValidYet
+	}
+
+	if v.opts.Issuer != "" && claims.String("iss") != v.opts.Issuer {
+		return ErrIssuer
+	}
+
+	if len(v.opts.Audience) > 0 {
+		matched := false
+
+		for _, aud := range claims.Strings("aud") {
+			for _, expected := range v.opts.Audience {
+				if aud == expected {
+					matched = true
+				}
+			}
+		}
+
+		if !matched {
+			return ErrAudience
+		}
+	}
+
+	return nil
+}
+
+var hashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA256:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	default:
+		sum := sha512.Sum512(data)
+		return sum[:]
+	}
+}
+
+// verify checks the signature with the key.
+// The type of the key has to match the family of the algorithm,
+// so a public key can never be used as an HMAC secret.
+func verify(alg string, key any, signingInput []byte, signature []byte) error {
+	hash := hashes[alg]
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+
+		if !ok {
+			return ErrSignature
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signingInput)
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrSignature
+		}
+
+		return nil
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+
+		if !ok {
+			return ErrSignature
+		}
+
+		return rsa.VerifyPKCS1v15(pub, hash, digest(hash, signingInput), signature)
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+
+		if !ok {
+			return ErrSignature
+		}
+
+		return rsa.VerifyPSS(pub, hash, digest(hash, signingInput), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+
+		if !ok {
+			return ErrSignature
+		}
+
+		size := (pub.Curve.Params().BitSize + 7) / 8
+
+		if len(signature) != 2*size || hash.Size()*8 != curveHashBits(pub.Curve.Params().BitSize) {
+			return ErrSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		if !ecdsa.Verify(pub, digest(hash, signingInput), r, s) {
+			return ErrSignature
+		}
+
+		return nil
+	case "Ed":
+		pub, ok := key.(ed25519.PublicKey)
+
+		if !ok {
+			return ErrSignature
+		}
+
+		if !ed25519.Verify(pub, signingInput, signature) {
+			return ErrSignature
+		}
+
+		return nil
+	}
+
+	return ErrAlgorithm
+}
+
+// curveHashBits ES256 uses P-256, ES384 uses P-384 and ES512 uses P-521.
+func curveHashBits(curveBits int) int {
+	if curveBits == 521 {
+		return 512
+	}
+
+	return curveBits
+}
@@ -0,0 +1,254 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func sign(t *testing.T, alg string, kid string, key any, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := b64(header) + "." + b64(payload)
+	sum := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	var err error
+
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, sum[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signingInput))
+	}
+
+	assert.NoError(t, err)
+
+	return signingInput + "." + b64(signature)
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   b64(pub.N.Bytes()),
+		"e":   b64(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "alice",
+		"iss":   "https://idp.example.com",
+		"aud":   []string{"http-server"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"roles": []string{"admin"},
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	secret := []byte("secret")
+
+	v, err := NewVerifier(Options{Secret: secret, Algorithms: []string{"HS256"}})
+	assert.NoError(t, err)
+
+	claims, err := v.Verify(sign(t, "HS256", "", secret, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", claims.String("sub"))
+	assert.Equal(t, []string{"admin"}, claims.Strings("roles"))
+
+	_, err = v.Verify(sign(t, "HS256", "", []byte("wrong"), validClaims()))
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestVerifyJWKSRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var rotated atomic.Bool
+	var fetches atomic.Int32
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+
+		keys := []map[string]string{rsaJWK("old", &oldKey.PublicKey)}
+
+		if rotated.Load() {
+			keys = []map[string]string{rsaJWK("new", &newKey.PublicKey)}
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer jwks.Close()
+
+	v, err := NewVerifier(Options{
+		JWKSURL:  jwks.URL,
+		Issuer:   "https://idp.example.com",
+		Audience: []string{"http-server"},
+	})
+	assert.NoError(t, err)
+
+	_, err = v.Verify(sign(t, "RS256", "old", oldKey, validClaims()))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(sign(t, "RS256", "old", oldKey, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// the identity provider rotates its signing key
+	rotated.Store(true)
+	v.sources[0].(*RemoteKeySet).lastAttempt = time.Time{}
+
+	_, err = v.Verify(sign(t, "RS256", "new", newKey, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestVerifyES256AndEdDSA(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	v := &Verifier{
+		opts:       Options{Now: time.Now},
+		algorithms: map[string]bool{"ES256": true, "EdDSA": true},
+		sources: []KeySource{StaticKeySet{
+			{ID: "ec", Key: &ecKey.PublicKey},
+			{ID: "ed", Key: edPub},
+		}},
+	}
+
+	_, err := v.Verify(sign(t, "ES256", "ec", ecKey, validClaims()))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(sign(t, "EdDSA", "ed", edKey, validClaims()))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(sign(t, "HS256", "", []byte("secret"), validClaims()))
+	assert.ErrorIs(t, err, ErrAlgorithm)
+}
+
+func TestVerifyRegisteredClaims(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+
+	v, err := NewVerifier(Options{
+		Secret:    secret,
+		Issuer:    "https://idp.example.com",
+		Audience:  []string{"http-server"},
+		ClockSkew: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	claims := validClaims()
+	claims["exp"] = now.Add(-30 * time.Second).Unix()
+	_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+	assert.NoError(t, err, "within the clock skew")
+
+	claims["exp"] = now.Add(-2 * time.Minute).Unix()
+	_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	claims = validClaims()
+	claims["nbf"] = now.Add(2 * time.Minute).Unix()
+	_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+	assert.ErrorIs(t, err, ErrNotValidYet)
+
+	claims = validClaims()
+	claims["iss"] = "https://evil.example.com"
+	_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+	assert.ErrorIs(t, err, ErrIssuer)
+
+	claims = validClaims()
+	claims["aud"] = "other"
+	_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+	assert.ErrorIs(t, err, ErrAudience)
+
+	_, err = v.Verify("not-a-token")
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestVerifyTimeClaimsAreNumericDates(t *testing.T) {
+	secret := []byte("secret")
+
+	v, err := NewVerifier(Options{Secret: secret})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		claims := validClaims()
+		claims[name] = "9"
+		_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+		assert.ErrorIs(t, err, ErrMalformed, name)
+
+		claims[name] = nil
+		_, err = v.Verify(sign(t, "HS256", "", secret, claims))
+		assert.ErrorIs(t, err, ErrMalformed, name)
+	}
+}
+
+func TestVerifyRequireExp(t *testing.T) {
+	secret := []byte("secret")
+
+	claims := validClaims()
+	delete(claims, "exp")
+
+	token := sign(t, "HS256", "", secret, claims)
+
+	v, err := NewVerifier(Options{Secret: secret, RequireExp: true})
+	assert.NoError(t, err)
+
+	_, err = v.Verify(token)
+	assert.ErrorIs(t, err, ErrNoExpiry)
+
+	_, err = v.Verify(sign(t, "HS256", "", secret, validClaims()))
+	assert.NoError(t, err)
+
+	v, err = NewVerifier(Options{Secret: secret})
+	assert.NoError(t, err)
+
+	_, err = v.Verify(token)
+	assert.NoError(t, err)
+}
+
+func TestClaimsLookup(t *testing.T) {
+	claims := Claims{
+		"realm_access": map[string]any{"roles": []any{"reader", "writer"}},
+		"scope":        "openid profile",
+	}
+
+	assert.Equal(t, []string{"reader", "writer"}, claims.Strings("realm_access.roles"))
+	assert.Equal(t, []string{"openid", "profile"}, claims.Strings("scope"))
+	assert.Equal(t, "", claims.String("missing"))
+}
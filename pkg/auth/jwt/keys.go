@@ -0,0 +1,373 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSRefresh how long a fetched JWKS is cached
+const DefaultJWKSRefresh = time.Hour
+
+// minJWKSRefetch limits the refetches caused by unknown key ids
+const minJWKSRefetch = 10 * time.Second
+
+// Key a verification key
+type Key struct {
+	// ID the kid of the key, may be empty
+	ID string
+
+	// Algorithm the key is restricted to, may be empty
+	Algorithm string
+
+	// Key is []byte, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+	Key any
+}
+
+// KeySource provides the keys which may verify a token
+type KeySource interface {
+	// Keys returns the keys matching kid, or all the keys if kid is empty.
+	Keys(kid string) ([]Key, error)
+}
+
+// StaticKeySet a fixed list of keys
+type StaticKeySet []Key
+
+// Keys Keys
+func (s StaticKeySet) Keys(kid string) ([]Key, error) {
+	return filterKeys(s, kid), nil
+}
+
+func filterKeys(keys []Key, kid string) []Key {
+	if kid == "" {
+		return keys
+	}
+
+	matched := make([]Key, 0, 1)
+
+	for _, key := range keys {
+		if key.ID == "" || key.ID == kid {
+			matched = append(matched, key)
+		}
+	}
+
+	return matched
+}
+
+// FileKeySet keys loaded from a PEM or JWKS file, reloaded when the file changes
+type FileKeySet struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	keys    []Key
+}
+
+// NewFileKeySet NewFileKeySet
+func NewFileKeySet(path string) (*FileKeySet, error) {
+	s := &FileKeySet{path: path}
+
+	if _, err := s.Keys(""); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Keys Keys
+func (s *FileKeySet) Keys(kid string) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+
+	if err != nil {
+		if s.keys != nil {
+			// keep the last good keys while the file is being replaced
+			return filterKeys(s.keys, kid), nil
+		}
+
+		return nil, err
+	}
+
+	if s.keys == nil || !info.ModTime().Equal(s.modTime) {
+		data, err := os.ReadFile(s.path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := ParseKeys(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %s: %w", s.path, err)
+		}
+
+		s.keys = keys
+		s.modTime = info.ModTime()
+	}
+
+	return filterKeys(s.keys, kid), nil
+}
+
+// RemoteKeySet keys fetched from a JWKS url
+type RemoteKeySet struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+
+	mu          sync.Mutex
+	keys        []Key
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+// NewRemoteKeySet NewRemoteKeySet
+func NewRemoteKeySet(url string, client *http.Client, refresh time.Duration) *RemoteKeySet {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &RemoteKeySet{
+		url:     url,
+		client:  client,
+		refresh: refresh,
+	}
+}
+
+// Keys returns the cached keys. The JWKS is fetched again when the cache
+// is stale, or when kid is unknown, which is how signing key rotation shows up.
+func (s *RemoteKeySet) Keys(kid string) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	stale := s.keys == nil || now.Sub(s.fetched) > s.refresh
+	unknown := kid != "" && len(filterKeys(s.keys, kid)) == 0
+
+	if (stale || unknown) && now.Sub(s.lastAttempt) > minJWKSRefetch {
+		s.lastAttempt = now
+
+		keys, err := s.fetch()
+
+		if err != nil && s.keys == nil {
+			return nil, err
+		}
+
+		if err == nil {
+			s.keys = keys
+			s.fetched = now
+		}
+	}
+
+	return filterKeys(s.keys, kid), nil
+}
+
+func (s *RemoteKeySet) fetch() ([]Key, error) {
+	resp, err := s.client.Get(s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwt: fetch %s: %s", s.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+// ParseKeys parses PEM encoded public keys and certificates, or a JWKS document.
+func ParseKeys(data []byte) ([]Key, error) {
+	if block, _ := pem.Decode(data); block == nil {
+		return ParseJWKS(data)
+	}
+
+	keys := []Key{}
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+
+		if block == nil {
+			break
+		}
+
+		var key any
+		var err error
+
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+
+			cert, err = x509.ParseCertificate(block.Bytes)
+
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, Key{Key: key})
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no public key found")
+	}
+
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys which are not signing keys,
+// or which can not be parsed, are skipped.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, Key{ID: k.Kid, Algorithm: k.Alg, Key: key})
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,270 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"snowdream.tech/http-server/pkg/auth/jwt"
+)
+
+// ErrNonce the nonce of the ID token does not match the one of the login
+var ErrNonce = errors.New("oidc: invalid nonce")
+
+// Config Config of the relying party
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	ClockSkew    time.Duration
+	HTTPClient   *http.Client
+}
+
+// Provider the metadata of the OpenID provider, from its discovery document
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Token the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Client an OpenID Connect relying party using the authorization code flow with PKCE
+type Client struct {
+	conf Config
+
+	mu       sync.Mutex
+	provider *Provider
+	verifier *jwt.Verifier
+}
+
+// NewClient NewClient
+func NewClient(conf Config) *Client {
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	return &Client{conf: conf}
+}
+
+// Discover fetches the discovery document of the issuer once, and caches it.
+func (c *Client) Discover(ctx context.Context) (*Provider, *jwt.Verifier, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, c.verifier, nil
+	}
+
+	wellKnown := strings.TrimSuffix(c.conf.Issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.conf.HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("oidc: discovery %s: %s", wellKnown, resp.Status)
+	}
+
+	provider := &Provider{}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(provider); err != nil {
+		return nil, nil, err
+	}
+
+	if provider.Issuer != c.conf.Issuer {
+		return nil, nil, fmt.Errorf("oidc: issuer %q does not match %q", provider.Issuer, c.conf.Issuer)
+	}
+
+	verifier, err := jwt.NewVerifier(jwt.Options{
+		// ID tokens signed with HS256 use the client secret as the key
+		Secret:    []byte(c.conf.ClientSecret),
+		JWKSURL:   provider.JWKSURI,
+		Issuer:    c.conf.Issuer,
+		Audience:  []string{c.conf.ClientID},
+		ClockSkew: c.conf.ClockSkew,
+		// ID tokens always expire
+		RequireExp: true,
+		HTTPClient: c.conf.HTTPClient,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.provider = provider
+	c.verifier = verifier
+
+	return provider, verifier, nil
+}
+
+// AuthCodeURL returns the url of the authorization endpoint the browser is redirected to
+func (c *Client) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, codeVerifier string) (string, error) {
+	provider, _, err := c.Discover(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.conf.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(c.conf.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {S256Challenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	return appendQuery(provider.AuthorizationEndpoint, params), nil
+}
+
+// Exchange exchanges the authorization code for the tokens
+func (c *Client) Exchange(ctx context.Context, redirectURI, code, codeVerifier string) (*Token, error) {
+	provider, _, err := c.Discover(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+		"client_id":     {c.conf.ClientID},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if c.conf.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.conf.ClientID), url.QueryEscape(c.conf.ClientSecret))
+	}
+
+	resp, err := c.conf.HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint: %s", resp.Status)
+	}
+
+	token := &Token{}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(token); err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: no id_token in the token response")
+	}
+
+	return token, nil
+}
+
+// VerifyIDToken verifies the signature, the registered claims and the nonce of the ID token
+func (c *Client) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.Claims, error) {
+	_, verifier, err := c.Discover(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := verifier.Verify(rawIDToken)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.String("nonce") != nonce {
+		return nil, ErrNonce
+	}
+
+	return claims, nil
+}
+
+// LogoutURL returns the RP-initiated logout url of the provider,
+// or an empty string if the provider does not support it.
+func (c *Client) LogoutURL(ctx context.Context, postLogoutRedirectURI string) string {
+	provider, _, err := c.Discover(ctx)
+
+	if err != nil || provider.EndSessionEndpoint == "" {
+		return ""
+	}
+
+	params := url.Values{"client_id": {c.conf.ClientID}}
+
+	if postLogoutRedirectURI != "" {
+		params.Set("post_logout_redirect_uri", postLogoutRedirectURI)
+	}
+
+	return appendQuery(provider.EndSessionEndpoint, params)
+}
+
+// RandomString returns a url safe random string, used for state, nonce and PKCE verifier
+func RandomString() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// S256Challenge the PKCE code challenge of the verifier
+func S256Challenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func appendQuery(endpoint string, params url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + params.Encode()
+	}
+
+	return endpoint + "?" + params.Encode()
+}
@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProvider is a minimal OpenID provider, which remembers the
+// authorization request and checks the PKCE verifier at the token endpoint.
+type mockProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	p := &mockProvider{key: key}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Provider{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+			EndSessionEndpoint:    p.server.URL + "/logout",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		user, pass, _ := r.BasicAuth()
+
+		if user != "client" || pass != "secret" || r.Form.Get("code") != "the-code" ||
+			S256Challenge(r.Form.Get("code_verifier")) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(Token{
+			AccessToken: "access",
+			TokenType:   "Bearer",
+			IDToken:     p.idToken(t, "client"),
+			ExpiresIn:   3600,
+		})
+	})
+
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+func (p *mockProvider) idToken(t *testing.T, audience string) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(map[string]any{
+		"iss":                p.server.URL,
+		"sub":                "42",
+		"aud":                audience,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              p.nonce,
+		"preferred_username": "alice",
+		"groups":             []string{"Staff"},
+	})
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	assert.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	provider := newMockProvider(t)
+	defer provider.server.Close()
+
+	client := NewClient(Config{
+		Issuer:       provider.server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	})
+
+	ctx := context.Background()
+	redirectURI := "http://localhost:8080/oidc/callback"
+
+	state, nonce, verifier := RandomString(), RandomString(), RandomString()
+
+	authURL, err := client.AuthCodeURL(ctx, redirectURI, state, nonce, verifier)
+	assert.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, state, u.Query().Get("state"))
+
+	// what the provider remembers from the authorization request
+	provider.challenge = u.Query().Get("code_challenge")
+	provider.nonce = u.Query().Get("nonce")
+
+	_, err = client.Exchange(ctx, redirectURI, "the-code", "wrong-verifier")
+	assert.Error(t, err)
+
+	token, err := client.Exchange(ctx, redirectURI, "the-code", verifier)
+	assert.NoError(t, err)
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, nonce)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", claims.String("preferred_username"))
+	assert.Equal(t, []string{"Staff"}, claims.Strings("groups"))
+
+	_, err = client.VerifyIDToken(ctx, token.IDToken, "other-nonce")
+	assert.ErrorIs(t, err, ErrNonce)
+
+	_, err = client.VerifyIDToken(ctx, provider.idToken(t, "another-client"), nonce)
+	assert.Error(t, err)
+
+	logoutURL := client.LogoutURL(ctx, "http://localhost:8080/")
+	assert.Contains(t, logoutURL, provider.server.URL+"/logout?")
+	assert.Contains(t, logoutURL, "client_id=client")
+}
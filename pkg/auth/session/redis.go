@@ -0,0 +1,85 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	libredis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps the session in redis, and only a random session id in the cookie,
+// so that the sessions are shared by all the replicas and can be revoked.
+type RedisStore struct {
+	opts   CookieOptions
+	client *libredis.Client
+	prefix string
+}
+
+// NewRedisStore NewRedisStore
+func NewRedisStore(opts CookieOptions, client *libredis.Client) *RedisStore {
+	return &RedisStore{opts: opts, client: client, prefix: "Session:"}
+}
+
+// Load Load
+func (s *RedisStore) Load(c *gin.Context) (*Session, error) {
+	id, err := c.Cookie(s.opts.Name)
+
+	if err != nil || id == "" {
+		return nil, ErrNoSession
+	}
+
+	data, err := s.client.Get(c.Request.Context(), s.prefix+id).Bytes()
+
+	if err != nil {
+		return nil, ErrNoSession
+	}
+
+	sess := &Session{}
+
+	if err := json.Unmarshal(data, sess); err != nil || sess.Expired() {
+		return nil, ErrNoSession
+	}
+
+	return sess, nil
+}
+
+// Save Save
+func (s *RedisStore) Save(c *gin.Context, sess *Session) error {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(buf)
+
+	data, err := json.Marshal(sess)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.client.Set(c.Request.Context(), s.prefix+id, data, time.Duration(s.opts.MaxAge)*time.Second).Err()
+
+	if err != nil {
+		return err
+	}
+
+	SetCookie(c, s.opts, id, s.opts.MaxAge)
+
+	return nil
+}
+
+// Delete Delete
+func (s *RedisStore) Delete(c *gin.Context) error {
+	if id, err := c.Cookie(s.opts.Name); err == nil && id != "" {
+		s.client.Del(c.Request.Context(), s.prefix+id)
+	}
+
+	SetCookie(c, s.opts, "", -1)
+
+	return nil
+}
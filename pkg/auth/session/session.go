@@ -0,0 +1,171 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNoSession the request has no valid session
+var ErrNoSession = errors.New("session: no session")
+
+// Session the browser session of a logged in user
+type Session struct {
+	User    string   `json:"user"`
+	Roles   []string `json:"roles"`
+	Expires int64    `json:"expires"`
+}
+
+// Expired reports whether the session is expired
+func (s *Session) Expired() bool {
+	return s.Expires != 0 && time.Now().Unix() >= s.Expires
+}
+
+// Store loads and saves the session of a request
+type Store interface {
+	Load(c *gin.Context) (*Session, error)
+	Save(c *gin.Context, s *Session) error
+	Delete(c *gin.Context) error
+}
+
+// Codec encrypts and authenticates the values stored in cookies with AES-GCM
+type Codec struct {
+	aead cipher.AEAD
+}
+
+// NewCodec derives the AES-256 key from secret.
+// If secret is empty a random key is used, and the cookies do not survive a restart.
+func NewCodec(secret string) *Codec {
+	var key [32]byte
+
+	if secret == "" {
+		rand.Read(key[:])
+	} else {
+		key = sha256.Sum256([]byte(secret))
+	}
+
+	block, _ := aes.NewCipher(key[:])
+	aead, _ := cipher.NewGCM(block)
+
+	return &Codec{aead: aead}
+}
+
+// Encode marshals v to json, and encrypts it
+func (c *Codec) Encode(v any) (string, error) {
+	plaintext, err := json.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(c.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decode decrypts value, and unmarshals it into v
+func (c *Codec) Decode(value string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return ErrNoSession
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+
+	if err != nil {
+		return ErrNoSession
+	}
+
+	return json.Unmarshal(plaintext, v)
+}
+
+// CookieOptions the attributes of the session cookie
+type CookieOptions struct {
+	Name   string
+	Path   string
+	MaxAge int
+}
+
+// SetCookie sets an HttpOnly, SameSite=Lax cookie, which is Secure on https requests
+func SetCookie(c *gin.Context, opts CookieOptions, value string, maxAge int) {
+	path := opts.Path
+
+	if path == "" {
+		path = "/"
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(opts.Name, value, maxAge, path, "", IsSecure(c), true)
+}
+
+// IsSecure reports whether the request came over https, directly or through a proxy
+func IsSecure(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// CookieStore keeps the whole session, encrypted, in the cookie
+type CookieStore struct {
+	opts  CookieOptions
+	codec *Codec
+}
+
+// NewCookieStore NewCookieStore
+func NewCookieStore(opts CookieOptions, codec *Codec) *CookieStore {
+	return &CookieStore{opts: opts, codec: codec}
+}
+
+// Load Load
+func (s *CookieStore) Load(c *gin.Context) (*Session, error) {
+	value, err := c.Cookie(s.opts.Name)
+
+	if err != nil || value == "" {
+		return nil, ErrNoSession
+	}
+
+	sess := &Session{}
+
+	if err := s.codec.Decode(value, sess); err != nil {
+		return nil, ErrNoSession
+	}
+
+	if sess.Expired() {
+		return nil, ErrNoSession
+	}
+
+	return sess, nil
+}
+
+// Save Save
+func (s *CookieStore) Save(c *gin.Context, sess *Session) error {
+	value, err := s.codec.Encode(sess)
+
+	if err != nil {
+		return err
+	}
+
+	SetCookie(c, s.opts, value, s.opts.MaxAge)
+
+	return nil
+}
+
+// Delete Delete
+func (s *CookieStore) Delete(c *gin.Context) error {
+	SetCookie(c, s.opts, "", -1)
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	codec := NewCodec("secret")
+
+	value, err := codec.Encode(&Session{User: "alice", Roles: []string{"staff"}})
+	assert.NoError(t, err)
+
+	sess := &Session{}
+	assert.NoError(t, codec.Decode(value, sess))
+	assert.Equal(t, "alice", sess.User)
+	assert.Equal(t, []string{"staff"}, sess.Roles)
+
+	// tampered cookie
+	tampered := []byte(value)
+	tampered[len(tampered)-2] ^= 1
+	assert.ErrorIs(t, codec.Decode(string(tampered), sess), ErrNoSession)
+
+	// another secret
+	assert.ErrorIs(t, NewCodec("other").Decode(value, sess), ErrNoSession)
+}
@@ -0,0 +1,70 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// ACMEConfig ACME Config, the certificates are obtained from Let's Encrypt or another ACME CA
+type ACMEConfig struct {
+	Enable bool `mapstructure:"enable"`
+
+	// Domains the allowed server names, app.httpsdomains if it is empty
+	Domains []string `mapstructure:"domains"`
+
+	// Email the contact of the account, app.contactemail if it is empty
+	Email string `mapstructure:"email"`
+
+	// CacheDir the directory of the account key and the certificates, app.httpscertsdir if it is empty
+	CacheDir string `mapstructure:"cachedir"`
+
+	// DirectoryURL the directory of the CA, Let's Encrypt if it is empty
+	DirectoryURL string `mapstructure:"directoryurl"`
+
+	// CAFile a PEM bundle trusted for the connections to the CA, eg: a private CA
+	CAFile string `mapstructure:"cafile"`
+
+	// EABKeyID and EABHMACKey the External Account Binding required by some CAs,
+	// the key is base64url encoded
+	EABKeyID   string `mapstructure:"eabkeyid"`
+	EABHMACKey string `mapstructure:"eabhmackey"`
+
+	// RenewBefore how long before expiry the certificates are renewed, in seconds.
+	// If it is 0, they are renewed 30 days before.
+	RenewBefore int64 `mapstructure:"renewbefore"`
+}
+
+var defaultACMEConfig = ACMEConfig{
+	Enable:       false,
+	Domains:      nil,
+	Email:        "",
+	CacheDir:     "",
+	DirectoryURL: "",
+	CAFile:       "",
+	EABKeyID:     "",
+	EABHMACKey:   "",
+	RenewBefore:  0,
+}
+
+// GetACMEConfigWithContext Get ACMEConfig from context
+func GetACMEConfigWithContext(c *gin.Context) (acmeConfig *ACMEConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.ACME
+}
+
+// GetACMEConfig Get ACMEConfig from context
+func GetACMEConfig() (acmeConfig *ACMEConfig) {
+	if c == nil {
+		return &defaultACMEConfig
+	}
+
+	return &c.ACME
+}
@@ -0,0 +1,56 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// AdminConfig Admin Dashboard Config
+//
+// The requests of the admin api which change the state take a json body,
+// and need an X-Requested-With header, so that other sites cannot forge them.
+type AdminConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Prefix string `mapstructure:"prefix"`
+
+	// User user:password of the basic auth of the admin
+	User string `mapstructure:"user"`
+
+	// Token the bearer token of the admin
+	Token string `mapstructure:"token"`
+
+	// Roles the users authenticated by basic auth, jwt, oidc or a client certificate
+	// with one of these roles are admins
+	Roles []string `mapstructure:"roles"`
+}
+
+var defaultAdminConfig = AdminConfig{
+	Enable: false,
+	Prefix: "/admin",
+	User:   "",
+	Token:  "",
+	Roles:  []string{"admin"},
+}
+
+// GetAdminConfigWithContext Get AdminConfig from context
+func GetAdminConfigWithContext(c *gin.Context) (adminConfig *AdminConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Admin
+}
+
+// GetAdminConfig Get AdminConfig from context
+func GetAdminConfig() (adminConfig *AdminConfig) {
+	if c == nil {
+		return &defaultAdminConfig
+	}
+
+	return &c.Admin
+}
@@ -4,53 +4,113 @@ import "github.com/gin-gonic/gin"
 
 // AppConfig App Config
 type AppConfig struct {
-	Host                string   `mapstructure:"host"`
-	Port                string   `mapstructure:"port"`
-	Basic               bool     `mapstructure:"basic"`
-	Gzip                bool     `mapstructure:"gzip"`
-	User                string   `mapstructure:"user"`
-	LogDir              string   `mapstructure:"logdir"`
-	RateLimiter         string   `mapstructure:"ratelimiter"`
-	ReadTimeout         int64    `mapstructure:"readtimeout"`
-	WriteTimeout        int64    `mapstructure:"writetimeout"`
-	WwwRoot             string   `mapstructure:"wwwroot"`
-	AutoIndexTimeFormat string   `mapstructure:"autoindextimeformat"`
-	AutoIndexExactSize  bool     `mapstructure:"autoindexexactsize"`
-	PreviewHTML         bool     `mapstructure:"previewhtml"`
-	EnableHTTPS         bool     `mapstructure:"enablehttps"`
-	HTTPSPort           string   `mapstructure:"httpsport"`
-	HTTPSCertFile       string   `mapstructure:"httpscertfile"`
-	HTTPSKeyFile        string   `mapstructure:"httpskeyfile"`
-	HTTPSCertsDir       string   `mapstructure:"httpscertsdir"`
-	HTTPSDomains        []string `mapstructure:"httpsdomains"`
-	ContactEmail        string   `mapstructure:"contactemail"`
-	SpeedLimiter        int64    `mapstructure:"speedlimiter"`
-	RefererLimiter      bool     `mapstructure:"refererlimiter"`
+	Host                  string   `mapstructure:"host"`
+	Port                  string   `mapstructure:"port"`
+	Basic                 bool     `mapstructure:"basic"`
+	Gzip                  bool     `mapstructure:"gzip"`
+	User                  string   `mapstructure:"user"`
+	LogDir                string   `mapstructure:"logdir"`
+	RateLimiter           string   `mapstructure:"ratelimiter"`
+	ReadTimeout           int64    `mapstructure:"readtimeout"`
+	WriteTimeout          int64    `mapstructure:"writetimeout"`
+	WwwRoot               string   `mapstructure:"wwwroot"`
+	AutoIndexTimeFormat   string   `mapstructure:"autoindextimeformat"`
+	AutoIndexExactSize    bool     `mapstructure:"autoindexexactsize"`
+	AutoIndexDownloads    bool     `mapstructure:"autoindexdownloads"`
+	PreviewHTML           bool     `mapstructure:"previewhtml"`
+	EnableHTTPS           bool     `mapstructure:"enablehttps"`
+	HTTPSPort             string   `mapstructure:"httpsport"`
+	Listen                []string `mapstructure:"listen"`
+	HTTPSListen           []string `mapstructure:"httpslisten"`
+	SocketMode            string   `mapstructure:"socketmode"`
+	SocketOwner           string   `mapstructure:"socketowner"`
+	TrustedProxies        []string `mapstructure:"trustedproxies"`
+	HTTPSCertFile         string   `mapstructure:"httpscertfile"`
+	HTTPSKeyFile          string   `mapstructure:"httpskeyfile"`
+	HTTPSCertsDir         string   `mapstructure:"httpscertsdir"`
+	HTTPSDomains          []string `mapstructure:"httpsdomains"`
+	ContactEmail          string   `mapstructure:"contactemail"`
+	HTTPSClientAuth       string   `mapstructure:"httpsclientauth"`
+	HTTPSClientCAFile     string   `mapstructure:"httpsclientcafile"`
+	HTTPSClientPaths      []string `mapstructure:"httpsclientpaths"`
+	HTTPSRedirect         bool     `mapstructure:"httpsredirect"`
+	HSTSMaxAge            int64    `mapstructure:"hstsmaxage"`
+	HSTSIncludeSubdomains bool     `mapstructure:"hstsincludesubdomains"`
+	HSTSPreload           bool     `mapstructure:"hstspreload"`
+	TLSPolicy             string   `mapstructure:"tlspolicy"`
+	TLSMinVersion         string   `mapstructure:"tlsminversion"`
+	TLSMaxVersion         string   `mapstructure:"tlsmaxversion"`
+	TLSCipherSuites       []string `mapstructure:"tlsciphersuites"`
+	TLSCurves             []string `mapstructure:"tlscurves"`
+	TLSALPN               []string `mapstructure:"tlsalpn"`
+	TLSSessionTickets     bool     `mapstructure:"tlssessiontickets"`
+	TLSTicketRotation     int64    `mapstructure:"tlsticketrotation"`
+	OCSPStapling          bool     `mapstructure:"ocspstapling"`
+	HTTP3                 bool     `mapstructure:"http3"`
+	H2C                   bool     `mapstructure:"h2c"`
+	HTTP2MaxStreams       int64    `mapstructure:"http2maxstreams"`
+	HTTP2MaxFrameSize     int64    `mapstructure:"http2maxframesize"`
+	AuditLog              bool     `mapstructure:"auditlog"`
+	AccessLogFormat       string   `mapstructure:"accesslogformat"`
+	LogLevel              string   `mapstructure:"loglevel"`
+	LogFormat             string   `mapstructure:"logformat"`
+	SpeedLimiter          int64    `mapstructure:"speedlimiter"`
+	RefererLimiter        bool     `mapstructure:"refererlimiter"`
 }
 
 var defaultAppConfig = AppConfig{
-	Host:                "",
-	Port:                "",
-	Basic:               false,
-	Gzip:                true,
-	User:                "admin:admin",
-	LogDir:              ".",
-	RateLimiter:         "",
-	ReadTimeout:         10,
-	WriteTimeout:        10,
-	WwwRoot:             "",
-	AutoIndexTimeFormat: "2006-01-02 15:04:05",
-	AutoIndexExactSize:  false,
-	PreviewHTML:         true,
-	EnableHTTPS:         false,
-	HTTPSPort:           "",
-	HTTPSCertFile:       "",
-	HTTPSKeyFile:        "",
-	HTTPSCertsDir:       "certs",
-	HTTPSDomains:        nil,
-	ContactEmail:        "",
-	SpeedLimiter:        0,
-	RefererLimiter:      false,
+	Host:                  "",
+	Port:                  "",
+	Basic:                 false,
+	Gzip:                  true,
+	User:                  "admin:admin",
+	LogDir:                ".",
+	RateLimiter:           "",
+	ReadTimeout:           10,
+	WriteTimeout:          10,
+	WwwRoot:               "",
+	AutoIndexTimeFormat:   "2006-01-02 15:04:05",
+	AutoIndexExactSize:    false,
+	AutoIndexDownloads:    false,
+	PreviewHTML:           true,
+	EnableHTTPS:           false,
+	HTTPSPort:             "",
+	Listen:                nil,
+	HTTPSListen:           nil,
+	SocketMode:            "0660",
+	SocketOwner:           "",
+	TrustedProxies:        nil,
+	HTTPSCertFile:         "",
+	HTTPSKeyFile:          "",
+	HTTPSCertsDir:         "certs",
+	HTTPSDomains:          nil,
+	ContactEmail:          "",
+	HTTPSClientAuth:       "none",
+	HTTPSClientCAFile:     "",
+	HTTPSClientPaths:      nil,
+	HTTPSRedirect:         false,
+	HSTSMaxAge:            0,
+	HSTSIncludeSubdomains: false,
+	HSTSPreload:           false,
+	TLSPolicy:             "intermediate",
+	TLSMinVersion:         "",
+	TLSMaxVersion:         "",
+	TLSCipherSuites:       nil,
+	TLSCurves:             nil,
+	TLSALPN:               nil,
+	TLSSessionTickets:     true,
+	TLSTicketRotation:     0,
+	OCSPStapling:          false,
+	HTTP3:                 false,
+	H2C:                   false,
+	HTTP2MaxStreams:       0,
+	HTTP2MaxFrameSize:     0,
+	AuditLog:              true,
+	AccessLogFormat:       "default",
+	LogLevel:              "",
+	LogFormat:             "text",
+	SpeedLimiter:          0,
+	RefererLimiter:        false,
 }
 
 // GetAppConfigWithContext Get AppConfig from context
@@ -0,0 +1,59 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// BruteForceConfig Brute-force protection Config
+type BruteForceConfig struct {
+	Enable      bool     `mapstructure:"enable"`
+	Store       string   `mapstructure:"store"`
+	MaxFailures int64    `mapstructure:"maxfailures"`
+	Window      int64    `mapstructure:"window"`
+	Backoff     int64    `mapstructure:"backoff"`
+	MaxBackoff  int64    `mapstructure:"maxbackoff"`
+	BanTime     int64    `mapstructure:"bantime"`
+	MaxBanTime  int64    `mapstructure:"maxbantime"`
+	AllowList   []string `mapstructure:"allowlist"`
+
+	// UserLockout bans the usernames after MaxFailures too, from every ip.
+	// Anyone who knows a username can lock it out, eg: admin, so it is off by default.
+	UserLockout bool `mapstructure:"userlockout"`
+}
+
+var defaultBruteForceConfig = BruteForceConfig{
+	Enable:      true,
+	Store:       "memory",
+	MaxFailures: 5,
+	Window:      600,
+	Backoff:     1,
+	MaxBackoff:  30,
+	BanTime:     900,
+	MaxBanTime:  86400,
+	AllowList:   []string{"127.0.0.1", "::1"},
+	UserLockout: false,
+}
+
+// GetBruteForceConfigWithContext Get BruteForceConfig from context
+func GetBruteForceConfigWithContext(c *gin.Context) (bruteForceConfig *BruteForceConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.BruteForce
+}
+
+// GetBruteForceConfig Get BruteForceConfig from context
+func GetBruteForceConfig() (bruteForceConfig *BruteForceConfig) {
+	if c == nil {
+		return &defaultBruteForceConfig
+	}
+
+	return &c.BruteForce
+}
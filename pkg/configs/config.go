@@ -1,9 +1,12 @@
 package configs
 
 import (
+	"errors"
+
 	"github.com/fsnotify/fsnotify"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"snowdream.tech/http-server/pkg/audit"
 	"snowdream.tech/http-server/pkg/env"
 	"snowdream.tech/http-server/pkg/os"
 	"snowdream.tech/http-server/pkg/tools"
@@ -37,23 +40,47 @@ const (
 
 // Configs Configs
 type Configs struct {
-	Version  string         `mapstructure:"version"`
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
+	Version    string           `mapstructure:"version"`
+	App        AppConfig        `mapstructure:"app"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	OIDC       OIDCConfig       `mapstructure:"oidc"`
+	BruteForce BruteForceConfig `mapstructure:"bruteforce"`
+	Log        LogConfig        `mapstructure:"log"`
+	RequestID  RequestIDConfig  `mapstructure:"requestid"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Tracing    TracingConfig    `mapstructure:"tracing"`
+	Health     HealthConfig     `mapstructure:"health"`
+	Admin      AdminConfig      `mapstructure:"admin"`
+	Share      ShareConfig      `mapstructure:"share"`
+	ACME       ACMEConfig       `mapstructure:"acme"`
+	TLS        TLSConfig        `mapstructure:"tls"`
 }
 
 var c *Configs = &Configs{
-	App:      defaultAppConfig,
-	Database: defaultDatabaseConfig,
-	Redis:    defaultRedisConfig,
+	App:        defaultAppConfig,
+	Database:   defaultDatabaseConfig,
+	Redis:      defaultRedisConfig,
+	JWT:        defaultJWTConfig,
+	OIDC:       defaultOIDCConfig,
+	BruteForce: defaultBruteForceConfig,
+	Log:        defaultLogConfig,
+	RequestID:  defaultRequestIDConfig,
+	Metrics:    defaultMetricsConfig,
+	Tracing:    defaultTracingConfig,
+	Health:     defaultHealthConfig,
+	Admin:      defaultAdminConfig,
+	Share:      defaultShareConfig,
+	ACME:       defaultACMEConfig,
+	TLS:        defaultTLSConfig,
 }
 
 // InitConfig init config
 func InitConfig() (conf *Configs) {
 	if configFile != "" {
 		if os.IsExistFile(configFile) {
-			// tools.DebugPrintF("[WARNING] %s does not exist or is Not a os.", configFile)
+			// tools.Logger("configs").Warn("The config file does not exist or is Not a file", "file", configFile)
 			return nil
 		}
 
@@ -95,7 +122,7 @@ func InitConfig() (conf *Configs) {
 		}
 
 		if configFile == "" || !os.IsExistFile(configFile) {
-			// tools.DebugPrintF("[WARNING] %s.(json/env/ini/yaml/toml/hcl/properties) does not exist or is Not a os.", configName)
+			// tools.Logger("configs").Warn("The config file .(json/env/ini/yaml/toml/hcl/properties) does not exist or is Not a file", "name", configName)
 
 			return nil
 		}
@@ -106,34 +133,65 @@ func InitConfig() (conf *Configs) {
 	err := viper.ReadInConfig()
 
 	if err != nil {
-		tools.DebugPrintF("[WARNING] Failed to read the config file %s,\n Error:\n %s .", viper.ConfigFileUsed(), err)
+		tools.Logger("configs").Warn("Failed to read the config file", "file", viper.ConfigFileUsed(), "error", err)
 		return c
 	}
 
-	tools.DebugPrintF("[INFO] The config file %s has been used.", viper.ConfigFileUsed())
+	tools.Logger("configs").Info("The config file has been used", "file", viper.ConfigFileUsed())
 
 	err = viper.Unmarshal(&c)
 
 	if err != nil {
-		tools.DebugPrintF("[WARNING] Failed to unmarshal the config file %s,\n Error:\n %s .", viper.ConfigFileUsed(), err)
+		tools.Logger("configs").Warn("Failed to unmarshal the config file", "file", viper.ConfigFileUsed(), "error", err)
 		return c
 	}
 
-	tools.DebugPrintF("[INFO] The config file %s has been Unmarshalled.", viper.ConfigFileUsed())
+	tools.Logger("configs").Debug("The config file has been unmarshalled", "file", viper.ConfigFileUsed())
 
 	viper.WatchConfig()
 
 	viper.OnConfigChange(func(e fsnotify.Event) {
-		tools.DebugPrintF("[INFO] The config file %s has been changed.", e.Name)
+		tools.Logger("configs").Info("The config file has been changed", "file", e.Name)
 
-		if err := viper.Unmarshal(&c); err != nil {
-			tools.DebugPrintF("[WARNING] Unmarshal conf failed, err:%s ", err)
-		}
+		unmarshalChanged(e.Name)
 	})
 
 	return c
 }
 
+// Reload reads the config file again, eg: when asked by an admin.
+// Like the changes of the watched file, only the settings read per request are applied.
+func Reload() error {
+	file := viper.ConfigFileUsed()
+
+	if file == "" {
+		return errors.New("no config file is used")
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		audit.Log(audit.Event{Action: audit.ActionConfigChange, Result: audit.ResultFailure, Path: file, Detail: err.Error()})
+		return err
+	}
+
+	tools.Logger("configs").Info("The config file has been reloaded", "file", file)
+
+	return unmarshalChanged(file)
+}
+
+// unmarshalChanged unmarshals the config file which has been read again
+func unmarshalChanged(file string) error {
+	if err := viper.Unmarshal(&c); err != nil {
+		tools.Logger("configs").Warn("Failed to unmarshal the changed config file", "file", file, "error", err)
+
+		audit.Log(audit.Event{Action: audit.ActionConfigChange, Result: audit.ResultFailure, Path: file, Detail: err.Error()})
+		return err
+	}
+
+	audit.Log(audit.Event{Action: audit.ActionConfigChange, Result: audit.ResultSuccess, Path: file})
+
+	return nil
+}
+
 // ConfigFile config file path
 func ConfigFile() *string {
 	return &configFile
@@ -4,6 +4,10 @@ import "github.com/gin-gonic/gin"
 
 // DatabaseConfig Database Config
 type DatabaseConfig struct {
+	// Enable records the downloads in the database
+	Enable bool `mapstructure:"enable"`
+
+	// Type pg or sqlite, the DSN of sqlite is the path of the database file
 	Type     string `mapstructure:"type"`
 	DSN      string `mapstructure:"dsn"`
 	Host     string `mapstructure:"host"`
@@ -15,6 +19,7 @@ type DatabaseConfig struct {
 }
 
 var defaultDatabaseConfig = DatabaseConfig{
+	Enable:   false,
 	Type:     "pg",
 	Host:     "localhost",
 	Port:     5432,
@@ -0,0 +1,66 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// HealthConfig Health Check Config
+type HealthConfig struct {
+	// Enable serves the probes, they shadow the files of the same paths in the wwwroot
+	Enable bool `mapstructure:"enable"`
+
+	// Details returns the result of every readiness check, eg: the errors of the database
+	// or the subjects of the certificates, to anonymous clients.
+	// Otherwise the probes only answer with their status code.
+	Details bool `mapstructure:"details"`
+
+	// LivenessPath reports that the process is alive
+	LivenessPath string `mapstructure:"livenesspath"`
+
+	// ReadinessPath reports that the server is ready to serve
+	ReadinessPath string `mapstructure:"readinesspath"`
+
+	// CertExpiry the certificates which expire within CertExpiry seconds are not ready
+	CertExpiry int64 `mapstructure:"certexpiry"`
+
+	// Timeout of the readiness checks, in seconds
+	Timeout int64 `mapstructure:"timeout"`
+
+	// ShutdownDelay the readiness fails for ShutdownDelay seconds before the servers are shut down,
+	// so that the load balancers stop sending requests, eg: two periods of their probes
+	ShutdownDelay int64 `mapstructure:"shutdowndelay"`
+}
+
+var defaultHealthConfig = HealthConfig{
+	Enable:        false,
+	Details:       false,
+	LivenessPath:  "/healthz",
+	ReadinessPath: "/readyz",
+	CertExpiry:    604800,
+	Timeout:       5,
+	ShutdownDelay: 0,
+}
+
+// GetHealthConfigWithContext Get HealthConfig from context
+func GetHealthConfigWithContext(c *gin.Context) (healthConfig *HealthConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Health
+}
+
+// GetHealthConfig Get HealthConfig from context
+func GetHealthConfig() (healthConfig *HealthConfig) {
+	if c == nil {
+		return &defaultHealthConfig
+	}
+
+	return &c.Health
+}
@@ -0,0 +1,66 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// JWTConfig JWT Config
+type JWTConfig struct {
+	Enable      bool              `mapstructure:"enable"`
+	Paths       []string          `mapstructure:"paths"`
+	Algorithms  []string          `mapstructure:"algorithms"`
+	Secret      string            `mapstructure:"secret"`
+	KeyFile     string            `mapstructure:"keyfile"`
+	JWKSURL     string            `mapstructure:"jwksurl"`
+	JWKSRefresh int64             `mapstructure:"jwksrefresh"`
+	Issuer      string            `mapstructure:"issuer"`
+	Audience    []string          `mapstructure:"audience"`
+	ClockSkew   int64             `mapstructure:"clockskew"`
+	RequireExp  bool              `mapstructure:"requireexp"`
+	UserClaim   string            `mapstructure:"userclaim"`
+	RolesClaim  string            `mapstructure:"rolesclaim"`
+	RoleMap     map[string]string `mapstructure:"rolemap"`
+	Roles       []string          `mapstructure:"roles"`
+}
+
+var defaultJWTConfig = JWTConfig{
+	Enable:      false,
+	Paths:       []string{"/"},
+	Algorithms:  nil,
+	Secret:      "",
+	KeyFile:     "",
+	JWKSURL:     "",
+	JWKSRefresh: 3600,
+	Issuer:      "",
+	Audience:    nil,
+	ClockSkew:   60,
+	RequireExp:  true,
+	UserClaim:   "sub",
+	RolesClaim:  "roles",
+	RoleMap:     nil,
+	Roles:       nil,
+}
+
+// GetJWTConfigWithContext Get JWTConfig from context
+func GetJWTConfigWithContext(c *gin.Context) (jwtConfig *JWTConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.JWT
+}
+
+// GetJWTConfig Get JWTConfig from context
+func GetJWTConfig() (jwtConfig *JWTConfig) {
+	if c == nil {
+		return &defaultJWTConfig
+	}
+
+	return &c.JWT
+}
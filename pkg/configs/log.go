@@ -0,0 +1,108 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// LogStreamConfig the destinations and the rotation of one log stream
+type LogStreamConfig struct {
+	// File the log file, relative to the log directory, disabled if empty
+	File       string `mapstructure:"file"`
+	MaxSize    int    `mapstructure:"maxsize"`
+	MaxBackups int    `mapstructure:"maxbackups"`
+	MaxAge     int    `mapstructure:"maxage"`
+	Compress   bool   `mapstructure:"compress"`
+	Daily      bool   `mapstructure:"daily"`
+
+	// Console stdout, stderr or none
+	Console  string `mapstructure:"console"`
+	Syslog   bool   `mapstructure:"syslog"`
+	Journald bool   `mapstructure:"journald"`
+}
+
+// LogConfig Log Config, of the gin, access, error and audit streams
+type LogConfig struct {
+	Gin    LogStreamConfig `mapstructure:"gin"`
+	Access LogStreamConfig `mapstructure:"access"`
+	Error  LogStreamConfig `mapstructure:"error"`
+
+	// Audit the audit log, its rotated files are kept forever by default (MaxBackups and MaxAge 0)
+	Audit LogStreamConfig `mapstructure:"audit"`
+
+	// SyslogTag the tag of syslog and journald, the project name if empty
+	SyslogTag      string `mapstructure:"syslogtag"`
+	SyslogFacility string `mapstructure:"syslogfacility"`
+}
+
+var defaultLogConfig = LogConfig{
+	Gin: LogStreamConfig{
+		File:       "gin.log",
+		MaxSize:    500,
+		MaxBackups: 3,
+		MaxAge:     28,
+		Compress:   true,
+		Daily:      false,
+		Console:    "stdout",
+		Syslog:     false,
+		Journald:   false,
+	},
+	Access: LogStreamConfig{
+		File:       "access.log",
+		MaxSize:    500,
+		MaxBackups: 3,
+		MaxAge:     28,
+		Compress:   true,
+		Daily:      false,
+		Console:    "stdout",
+		Syslog:     false,
+		Journald:   false,
+	},
+	Error: LogStreamConfig{
+		File:       "error.log",
+		MaxSize:    500,
+		MaxBackups: 3,
+		MaxAge:     28,
+		Compress:   true,
+		Daily:      false,
+		Console:    "none",
+		Syslog:     false,
+		Journald:   false,
+	},
+	Audit: LogStreamConfig{
+		File:       "audit.log",
+		MaxSize:    500,
+		MaxBackups: 0,
+		MaxAge:     0,
+		Compress:   true,
+		Daily:      false,
+		Console:    "none",
+		Syslog:     false,
+		Journald:   false,
+	},
+	SyslogTag:      "",
+	SyslogFacility: "daemon",
+}
+
+// GetLogConfigWithContext Get LogConfig from context
+func GetLogConfigWithContext(c *gin.Context) (logConfig *LogConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Log
+}
+
+// GetLogConfig Get LogConfig from context
+func GetLogConfig() (logConfig *LogConfig) {
+	if c == nil {
+		return &defaultLogConfig
+	}
+
+	return &c.Log
+}
@@ -0,0 +1,57 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// MetricsConfig Prometheus Metrics Config
+type MetricsConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Path   string `mapstructure:"path"`
+
+	// Listen serves the metrics on a separate address, eg: 127.0.0.1:9100,
+	// instead of the path of the web server
+	Listen string `mapstructure:"listen"`
+
+	// User user:password of the basic auth of the metrics
+	User string `mapstructure:"user"`
+
+	// Token the bearer token of the metrics
+	//
+	// When neither User nor Token is set, the metrics path of the web server
+	// requires the basic auth of the web server, and it is not served at all
+	// when the web server requires a JWT, an OIDC session or a client certificate.
+	Token string `mapstructure:"token"`
+}
+
+var defaultMetricsConfig = MetricsConfig{
+	Enable: false,
+	Path:   "/metrics",
+	Listen: "",
+	User:   "",
+	Token:  "",
+}
+
+// GetMetricsConfigWithContext Get MetricsConfig from context
+func GetMetricsConfigWithContext(c *gin.Context) (metricsConfig *MetricsConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Metrics
+}
+
+// GetMetricsConfig Get MetricsConfig from context
+func GetMetricsConfig() (metricsConfig *MetricsConfig) {
+	if c == nil {
+		return &defaultMetricsConfig
+	}
+
+	return &c.Metrics
+}
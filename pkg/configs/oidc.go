@@ -0,0 +1,74 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// OIDCConfig OpenID Connect Config
+type OIDCConfig struct {
+	Enable                bool              `mapstructure:"enable"`
+	Paths                 []string          `mapstructure:"paths"`
+	Issuer                string            `mapstructure:"issuer"`
+	ClientID              string            `mapstructure:"clientid"`
+	ClientSecret          string            `mapstructure:"clientsecret"`
+	Scopes                []string          `mapstructure:"scopes"`
+	RedirectURL           string            `mapstructure:"redirecturl"`
+	LoginPath             string            `mapstructure:"loginpath"`
+	CallbackPath          string            `mapstructure:"callbackpath"`
+	LogoutPath            string            `mapstructure:"logoutpath"`
+	PostLogoutRedirectURL string            `mapstructure:"postlogoutredirecturl"`
+	SessionStore          string            `mapstructure:"sessionstore"`
+	SessionSecret         string            `mapstructure:"sessionsecret"`
+	SessionMaxAge         int               `mapstructure:"sessionmaxage"`
+	CookieName            string            `mapstructure:"cookiename"`
+	UserClaim             string            `mapstructure:"userclaim"`
+	GroupsClaim           string            `mapstructure:"groupsclaim"`
+	RoleMap               map[string]string `mapstructure:"rolemap"`
+	Roles                 []string          `mapstructure:"roles"`
+}
+
+var defaultOIDCConfig = OIDCConfig{
+	Enable:                false,
+	Paths:                 []string{"/"},
+	Issuer:                "",
+	ClientID:              "",
+	ClientSecret:          "",
+	Scopes:                []string{"openid", "profile", "email"},
+	RedirectURL:           "",
+	LoginPath:             "/oidc/login",
+	CallbackPath:          "/oidc/callback",
+	LogoutPath:            "/oidc/logout",
+	PostLogoutRedirectURL: "",
+	SessionStore:          "cookie",
+	SessionSecret:         "",
+	SessionMaxAge:         8 * 3600,
+	CookieName:            "http_server_session",
+	UserClaim:             "preferred_username",
+	GroupsClaim:           "groups",
+	RoleMap:               nil,
+	Roles:                 nil,
+}
+
+// GetOIDCConfigWithContext Get OIDCConfig from context
+func GetOIDCConfigWithContext(c *gin.Context) (oidcConfig *OIDCConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.OIDC
+}
+
+// GetOIDCConfig Get OIDCConfig from context
+func GetOIDCConfig() (oidcConfig *OIDCConfig) {
+	if c == nil {
+		return &defaultOIDCConfig
+	}
+
+	return &c.OIDC
+}
@@ -0,0 +1,93 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Redacted the value of the secrets in Redact
+const Redacted = "******"
+
+// Redact returns the configs as a map, keyed like the config files, with the secrets hidden
+func Redact(conf *Configs) map[string]any {
+	m, _ := redact(reflect.ValueOf(conf)).(map[string]any)
+
+	return m
+}
+
+// isSecret reports whether the field holds a password, a token, a key, a user:password pair,
+// or a dsn which may contain a password
+func isSecret(name string) bool {
+	for _, secret := range []string{"password", "secret", "token", "hmac"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+
+	return name == "user" || name == "headers" || name == "dsn"
+}
+
+func redact(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return redact(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Tag.Get("mapstructure")
+
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+
+			if isSecret(name) && !v.Field(i).IsZero() {
+				m[name] = Redacted
+				continue
+			}
+
+			m[name] = redact(v.Field(i))
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		list := make([]any, v.Len())
+
+		for i := range list {
+			list[i] = redact(v.Index(i))
+		}
+
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		m := make(map[string]any, v.Len())
+
+		iter := v.MapRange()
+
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = redact(iter.Value())
+		}
+
+		return m
+	}
+
+	return v.Interface()
+}
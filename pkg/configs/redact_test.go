@@ -0,0 +1,35 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	conf := &Configs{
+		App:   AppConfig{User: "admin:admin", LogDir: "/var/log"},
+		Redis: RedisConfig{Host: "localhost", Password: "pass"},
+		OIDC:  OIDCConfig{ClientSecret: "secret", UserClaim: "preferred_username"},
+
+		Database: DatabaseConfig{DSN: "postgres://u:p@db/files"},
+	}
+
+	m := Redact(conf)
+
+	app := m["app"].(map[string]any)
+	assert.Equal(t, Redacted, app["user"])
+	assert.Equal(t, "/var/log", app["logdir"])
+
+	redis := m["redis"].(map[string]any)
+	assert.Equal(t, Redacted, redis["password"])
+	assert.Equal(t, "localhost", redis["host"])
+
+	oidc := m["oidc"].(map[string]any)
+	assert.Equal(t, Redacted, oidc["clientsecret"])
+	assert.Equal(t, "", oidc["sessionsecret"])
+	assert.Equal(t, "preferred_username", oidc["userclaim"])
+
+	database := m["database"].(map[string]any)
+	assert.Equal(t, Redacted, database["dsn"])
+}
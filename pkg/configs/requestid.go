@@ -0,0 +1,48 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// RequestIDConfig Request ID Config
+type RequestIDConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Header string `mapstructure:"header"`
+
+	// Format of the generated ids, uuid or ulid
+	Format string `mapstructure:"format"`
+
+	// Trust accepts the ids sent by the clients or the proxies
+	Trust bool `mapstructure:"trust"`
+}
+
+var defaultRequestIDConfig = RequestIDConfig{
+	Enable: true,
+	Header: "X-Request-ID",
+	Format: "uuid",
+	Trust:  true,
+}
+
+// GetRequestIDConfigWithContext Get RequestIDConfig from context
+func GetRequestIDConfigWithContext(c *gin.Context) (requestIDConfig *RequestIDConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.RequestID
+}
+
+// GetRequestIDConfig Get RequestIDConfig from context
+func GetRequestIDConfig() (requestIDConfig *RequestIDConfig) {
+	if c == nil {
+		return &defaultRequestIDConfig
+	}
+
+	return &c.RequestID
+}
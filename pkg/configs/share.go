@@ -0,0 +1,50 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// ShareConfig Share Links Config
+type ShareConfig struct {
+	Enable bool `mapstructure:"enable"`
+
+	// Prefix the share links are served at <prefix>/<token>
+	Prefix string `mapstructure:"prefix"`
+
+	// TTL the default lifetime of a share link, in seconds
+	TTL int64 `mapstructure:"ttl"`
+
+	// MaxTTL the longest lifetime of a share link, in seconds
+	MaxTTL int64 `mapstructure:"maxttl"`
+}
+
+var defaultShareConfig = ShareConfig{
+	Enable: false,
+	Prefix: "/share",
+	TTL:    86400,
+	MaxTTL: 2592000,
+}
+
+// GetShareConfigWithContext Get ShareConfig from context
+func GetShareConfigWithContext(c *gin.Context) (shareConfig *ShareConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Share
+}
+
+// GetShareConfig Get ShareConfig from context
+func GetShareConfig() (shareConfig *ShareConfig) {
+	if c == nil {
+		return &defaultShareConfig
+	}
+
+	return &c.Share
+}
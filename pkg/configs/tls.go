@@ -0,0 +1,52 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// TLSCertificateConfig a pair of certificate and key files
+type TLSCertificateConfig struct {
+	CertFile string `mapstructure:"certfile"`
+	KeyFile  string `mapstructure:"keyfile"`
+}
+
+// TLSConfig TLS Config, the certificates are selected by the server name (SNI)
+// of the clients, among their DNS names, wildcards included.
+type TLSConfig struct {
+	// Certificates the pairs of files served besides app.httpscertfile,
+	// which is the default certificate when it is set, otherwise it is the first pair.
+	Certificates []TLSCertificateConfig `mapstructure:"certificates"`
+
+	// CertificatesDir a directory of <name>.crt (or .pem, .cer) and <name>.key pairs,
+	// and of <name>/fullchain.pem and <name>/privkey.pem pairs, like certbot
+	CertificatesDir string `mapstructure:"certificatesdir"`
+}
+
+var defaultTLSConfig = TLSConfig{
+	Certificates:    nil,
+	CertificatesDir: "",
+}
+
+// GetTLSConfigWithContext Get TLSConfig from context
+func GetTLSConfigWithContext(c *gin.Context) (tlsConfig *TLSConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.TLS
+}
+
+// GetTLSConfig Get TLSConfig from context
+func GetTLSConfig() (tlsConfig *TLSConfig) {
+	if c == nil {
+		return &defaultTLSConfig
+	}
+
+	return &c.TLS
+}
@@ -0,0 +1,58 @@
+package configs
+
+import "github.com/gin-gonic/gin"
+
+// TracingConfig OpenTelemetry Tracing Config
+type TracingConfig struct {
+	Enable bool `mapstructure:"enable"`
+
+	// Exporter otlp-grpc or otlp-http
+	Exporter string `mapstructure:"exporter"`
+
+	// Endpoint host:port of the collector, eg: localhost:4317 for grpc, localhost:4318 for http
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+
+	// ServiceName the project name if empty
+	ServiceName string `mapstructure:"servicename"`
+
+	// SampleRatio the ratio of the traces started here which are sampled, from 0 to 1
+	SampleRatio float64 `mapstructure:"sampleratio"`
+}
+
+var defaultTracingConfig = TracingConfig{
+	Enable:      false,
+	Exporter:    "otlp-grpc",
+	Endpoint:    "localhost:4317",
+	Insecure:    true,
+	Headers:     nil,
+	ServiceName: "",
+	SampleRatio: 1,
+}
+
+// GetTracingConfigWithContext Get TracingConfig from context
+func GetTracingConfigWithContext(c *gin.Context) (tracingConfig *TracingConfig) {
+	value, exists := c.Get(ConfigKey)
+
+	if !exists {
+		return nil
+	}
+
+	confs, ok := value.(*Configs)
+
+	if !ok {
+		return nil
+	}
+
+	return &confs.Tracing
+}
+
+// GetTracingConfig Get TracingConfig from context
+func GetTracingConfig() (tracingConfig *TracingConfig) {
+	if c == nil {
+		return &defaultTracingConfig
+	}
+
+	return &c.Tracing
+}
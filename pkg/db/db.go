@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	// database drivers, both work without cgo
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/env"
+)
+
+// Dialects
+const (
+	// DialectPostgres PostgreSQL
+	DialectPostgres = "pg"
+
+	// DialectSQLite SQLite
+	DialectSQLite = "sqlite"
+)
+
+// DB a database, with its dialect
+type DB struct {
+	*sql.DB
+
+	Dialect string
+}
+
+// Open opens the database of the config
+func Open(conf *configs.DatabaseConfig) (*DB, error) {
+	var driver, dsn, dialect string
+
+	switch strings.ToLower(conf.Type) {
+	case "pg", "postgres", "postgresql":
+		driver, dsn, dialect = "pgx", postgresDSN(conf), DialectPostgres
+	case "sqlite", "sqlite3":
+		driver, dsn, dialect = "sqlite", sqliteDSN(conf), DialectSQLite
+	default:
+		return nil, fmt.Errorf("unknown database type %q, it should be pg or sqlite", conf.Type)
+	}
+
+	db, err := sql.Open(driver, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dialect == DialectSQLite {
+		// one writer at a time, the others wait for the busy timeout
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// Rebind converts the ? placeholders of the query to $1, $2... for PostgreSQL
+func (db *DB) Rebind(query string) string {
+	if db.Dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+
+	n := 0
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func postgresDSN(conf *configs.DatabaseConfig) string {
+	if conf.DSN != "" {
+		return conf.DSN
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(conf.User, conf.Password),
+		Host:   conf.Host + ":" + strconv.Itoa(conf.Port),
+		Path:   "/" + conf.Dbname,
+	}
+
+	if conf.TimeZone != "" {
+		u.RawQuery = url.Values{"timezone": {conf.TimeZone}}.Encode()
+	}
+
+	return u.String()
+}
+
+func sqliteDSN(conf *configs.DatabaseConfig) string {
+	file := conf.DSN
+
+	if file == "" {
+		file = env.ProjectName + ".db"
+	}
+
+	if strings.Contains(file, "?") {
+		return file
+	}
+
+	return "file:" + file + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func TestRebind(t *testing.T) {
+	pg := &DB{Dialect: DialectPostgres}
+	assert.Equal(t, "SELECT a FROM t WHERE b = $1 AND c IN ($2, $3)", pg.Rebind("SELECT a FROM t WHERE b = ? AND c IN (?, ?)"))
+
+	sqlite := &DB{Dialect: DialectSQLite}
+	assert.Equal(t, "SELECT ?", sqlite.Rebind("SELECT ?"))
+}
+
+func TestPostgresDSN(t *testing.T) {
+	dsn := postgresDSN(&configs.DatabaseConfig{Host: "db", Port: 5432, Dbname: "files", User: "u", Password: "p@ss", TimeZone: "UTC"})
+	assert.Equal(t, "postgres://u:p%40ss@db:5432/files?timezone=UTC", dsn)
+
+	_, err := Open(&configs.DatabaseConfig{Type: "mysql"})
+	assert.Error(t, err)
+}
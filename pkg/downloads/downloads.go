@@ -0,0 +1,311 @@
+package downloads
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"snowdream.tech/http-server/pkg/db"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// QueueSize the number of records waiting to be written, the next ones are dropped
+const QueueSize = 1024
+
+// maxBatch the number of records written in one transaction
+const maxBatch = 100
+
+// Record a finished download
+type Record struct {
+	Path      string    `json:"path" xml:"path" yaml:"path"`
+	Client    string    `json:"client" xml:"client" yaml:"client"`
+	User      string    `json:"user,omitempty" xml:"user,omitempty" yaml:"user,omitempty"`
+	Started   time.Time `json:"started" xml:"started" yaml:"started"`
+	Finished  time.Time `json:"finished" xml:"finished" yaml:"finished"`
+	Bytes     int64     `json:"bytes" xml:"bytes" yaml:"bytes"`
+	Status    int       `json:"status" xml:"status" yaml:"status"`
+	Completed bool      `json:"completed" xml:"completed" yaml:"completed"`
+
+	// Partial a range of the file, it only adds its bytes to the counts, not a download.
+	// It is not stored, the history shows the status 206.
+	Partial bool `json:"partial,omitempty" xml:"partial,omitempty" yaml:"partial,omitempty"`
+}
+
+// Count the downloads of a file
+type Count struct {
+	Path      string    `json:"path" xml:"path" yaml:"path"`
+	Downloads int64     `json:"downloads" xml:"downloads" yaml:"downloads"`
+	Completed int64     `json:"completed" xml:"completed" yaml:"completed"`
+	Aborted   int64     `json:"aborted" xml:"aborted" yaml:"aborted"`
+	Bytes     int64     `json:"bytes" xml:"bytes" yaml:"bytes"`
+	Last      time.Time `json:"last" xml:"last" yaml:"last"`
+}
+
+// Store records the downloads in the database, in the background
+type Store struct {
+	db *db.DB
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Record
+	done   chan struct{}
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// Default returns the store of the server, or nil if the database is disabled
+func Default() *Store {
+	return defaultStore.Load()
+}
+
+// SetDefault sets the store of the server
+func SetDefault(s *Store) {
+	defaultStore.Store(s)
+}
+
+// NewStore creates the tables if needed, and starts writing the records
+func NewStore(database *db.DB) (*Store, error) {
+	for _, stmt := range schema(database.Dialect) {
+		if _, err := database.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Store{
+		db:    database,
+		queue: make(chan Record, QueueSize),
+		done:  make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+// Record queues the record, it never blocks the download
+func (s *Store) Record(r Record) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.queue <- r:
+	default:
+		tools.Logger("downloads").Warn("The download queue is full, the record is dropped", "path", r.Path)
+	}
+}
+
+// Close writes the queued records, and stops
+func (s *Store) Close() {
+	s.mu.Lock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+
+	s.mu.Unlock()
+
+	<-s.done
+}
+
+// Counts returns the counts of the paths which have been downloaded
+func (s *Store) Counts(ctx context.Context, paths ...string) (map[string]Count, error) {
+	counts := make(map[string]Count, len(paths))
+
+	for len(paths) > 0 {
+		chunk := paths[:min(len(paths), 500)]
+		paths = paths[len(chunk):]
+
+		args := make([]any, 0, len(chunk))
+
+		for _, path := range chunk {
+			args = append(args, path)
+		}
+
+		query := "SELECT path, downloads, completed, aborted, bytes, last FROM download_counts WHERE path IN (?" +
+			strings.Repeat(", ?", len(chunk)-1) + ")"
+
+		list, err := s.queryCounts(ctx, query, args...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, count := range list {
+			counts[count.Path] = count
+		}
+	}
+
+	return counts, nil
+}
+
+// Top returns the most downloaded files
+func (s *Store) Top(ctx context.Context, limit int) ([]Count, error) {
+	return s.queryCounts(ctx, "SELECT path, downloads, completed, aborted, bytes, last FROM download_counts ORDER BY downloads DESC, path LIMIT ?", limit)
+}
+
+// History returns the last downloads of the path, or of all the files if path is empty
+func (s *Store) History(ctx context.Context, path string, limit int) ([]Record, error) {
+	query := "SELECT path, client, username, started, finished, bytes, status, completed FROM downloads"
+	args := []any{}
+
+	if path != "" {
+		query += " WHERE path = ?"
+		args = append(args, path)
+	}
+
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	records := []Record{}
+
+	for rows.Next() {
+		var r Record
+
+		if err := rows.Scan(&r.Path, &r.Client, &r.User, &r.Started, &r.Finished, &r.Bytes, &r.Status, &r.Completed); err != nil {
+			return nil, err
+		}
+
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+func (s *Store) queryCounts(ctx context.Context, query string, args ...any) ([]Count, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := []Count{}
+
+	for rows.Next() {
+		var count Count
+
+		if err := rows.Scan(&count.Path, &count.Downloads, &count.Completed, &count.Aborted, &count.Bytes, &count.Last); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// run writes the queued records, in batches
+func (s *Store) run() {
+	defer close(s.done)
+
+	for r := range s.queue {
+		batch := []Record{r}
+
+	drain:
+		for len(batch) < maxBatch {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break drain
+				}
+
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		if err := s.write(batch); err != nil {
+			tools.Logger("downloads").Error("Failed to record the downloads", "count", len(batch), "error", err)
+		}
+	}
+}
+
+func (s *Store) write(batch []Record) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	insert := s.db.Rebind("INSERT INTO downloads (path, client, username, started, finished, bytes, status, completed) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+
+	upsert := s.db.Rebind(`INSERT INTO download_counts (path, downloads, completed, aborted, bytes, last) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET
+downloads = download_counts.downloads + excluded.downloads,
+completed = download_counts.completed + excluded.completed,
+aborted = download_counts.aborted + excluded.aborted,
+bytes = download_counts.bytes + excluded.bytes,
+last = excluded.last`)
+
+	for _, r := range batch {
+		downloads, completed, aborted := 1, 0, 1
+
+		switch {
+		case r.Partial:
+			downloads, aborted = 0, 0
+		case r.Completed:
+			completed, aborted = 1, 0
+		}
+
+		if _, err := tx.Exec(insert, r.Path, r.Client, r.User, r.Started.UTC(), r.Finished.UTC(), r.Bytes, r.Status, r.Completed); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(upsert, r.Path, downloads, completed, aborted, r.Bytes, r.Finished.UTC()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// schema the tables of the downloads
+func schema(dialect string) []string {
+	id, timestamp := "INTEGER PRIMARY KEY AUTOINCREMENT", "TIMESTAMP"
+
+	if dialect == db.DialectPostgres {
+		id, timestamp = "BIGSERIAL PRIMARY KEY", "TIMESTAMPTZ"
+	}
+
+	return []string{
+		`CREATE TABLE IF NOT EXISTS downloads (
+id ` + id + `,
+path TEXT NOT NULL,
+client TEXT NOT NULL,
+username TEXT NOT NULL DEFAULT '',
+started ` + timestamp + ` NOT NULL,
+finished ` + timestamp + ` NOT NULL,
+bytes BIGINT NOT NULL,
+status INTEGER NOT NULL,
+completed BOOLEAN NOT NULL
+)`,
+		"CREATE INDEX IF NOT EXISTS downloads_path ON downloads (path, id)",
+		`CREATE TABLE IF NOT EXISTS download_counts (
+path TEXT PRIMARY KEY,
+downloads BIGINT NOT NULL,
+completed BIGINT NOT NULL,
+aborted BIGINT NOT NULL,
+bytes BIGINT NOT NULL,
+last ` + timestamp + ` NOT NULL
+)`,
+	}
+}
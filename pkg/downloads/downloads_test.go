@@ -0,0 +1,69 @@
+package downloads
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/db"
+)
+
+func TestStore(t *testing.T) {
+	database, err := db.Open(&configs.DatabaseConfig{
+		Type: "sqlite",
+		DSN:  filepath.Join(t.TempDir(), "downloads.db"),
+	})
+	assert.NoError(t, err)
+
+	defer database.Close()
+
+	store, err := NewStore(database)
+	assert.NoError(t, err)
+
+	started := time.Now().Add(-time.Second).Truncate(time.Millisecond)
+
+	store.Record(Record{Path: "/a.txt", Client: "10.0.0.1", Started: started, Finished: started.Add(time.Second), Bytes: 100, Status: 200, Completed: true})
+	store.Record(Record{Path: "/a.txt", Client: "10.0.0.2", User: "alice", Started: started, Finished: started.Add(time.Second), Bytes: 40, Status: 200})
+	store.Record(Record{Path: "/b.txt", Client: "10.0.0.1", Started: started, Finished: started.Add(time.Second), Bytes: 10, Status: 206, Completed: true})
+
+	// the segments of a parallel download only add their bytes
+	store.Record(Record{Path: "/b.txt", Client: "10.0.0.1", Started: started, Finished: started.Add(time.Second), Bytes: 5, Status: 206, Completed: true, Partial: true})
+	store.Record(Record{Path: "/b.txt", Client: "10.0.0.1", Started: started, Finished: started.Add(time.Second), Bytes: 5, Status: 206, Partial: true})
+
+	store.Close()
+
+	// closed stores drop the records
+	store.Record(Record{Path: "/c.txt"})
+
+	ctx := context.Background()
+
+	counts, err := store.Counts(ctx, "/a.txt", "/b.txt", "/c.txt")
+	assert.NoError(t, err)
+	assert.Len(t, counts, 2)
+	assert.Equal(t, Count{Path: "/a.txt", Downloads: 2, Completed: 1, Aborted: 1, Bytes: 140, Last: started.Add(time.Second).UTC()}, counts["/a.txt"])
+	assert.Equal(t, Count{Path: "/b.txt", Downloads: 1, Completed: 1, Aborted: 0, Bytes: 20, Last: started.Add(time.Second).UTC()}, counts["/b.txt"])
+
+	top, err := store.Top(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, top, 1)
+	assert.Equal(t, "/a.txt", top[0].Path)
+
+	history, err := store.History(ctx, "/a.txt", 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "alice", history[0].User)
+	assert.False(t, history[0].Completed)
+	assert.True(t, started.Equal(history[0].Started))
+
+	history, err = store.History(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 5)
+	assert.Equal(t, "/b.txt", history[0].Path)
+
+	// the tables are kept when the store is created again
+	_, err = NewStore(database)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatusOK the result of a passed check
+const StatusOK = "ok"
+
+// Check returns an error when a dependency of the server is not ready
+type Check func(ctx context.Context) error
+
+// Warning is returned by a check to report a problem which does not make the server not ready
+type Warning struct {
+	Err error
+}
+
+func (w Warning) Error() string {
+	return "warning: " + w.Err.Error()
+}
+
+func (w Warning) Unwrap() error {
+	return w.Err
+}
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]Check{}
+
+	shuttingDown atomic.Bool
+)
+
+// Register adds, or replaces, a readiness check
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	checks[name] = check
+}
+
+// SetShuttingDown makes the server not ready, so that no new traffic is sent to it
+func SetShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether the server is shutting down
+func ShuttingDown() bool {
+	return shuttingDown.Load()
+}
+
+// Ready runs the readiness checks, it returns the result of every check,
+// StatusOK or the error, and whether they all passed, warnings excepted.
+func Ready(ctx context.Context) (map[string]string, bool) {
+	mu.RLock()
+
+	snapshot := make(map[string]Check, len(checks))
+
+	for name, check := range checks {
+		snapshot[name] = check
+	}
+
+	mu.RUnlock()
+
+	results := make(map[string]string, len(snapshot)+1)
+	ready := true
+
+	if ShuttingDown() {
+		results["shutdown"] = "shutting down"
+		ready = false
+	}
+
+	for name, check := range snapshot {
+		var warning Warning
+
+		if err := check(ctx); errors.As(err, &warning) {
+			results[name] = err.Error()
+		} else if err != nil {
+			results[name] = err.Error()
+			ready = false
+		} else {
+			results[name] = StatusOK
+		}
+	}
+
+	return results, ready
+}
+
+// CheckDir returns an error if the directory can not be listed
+func CheckDir(dir string) error {
+	f, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if _, err := f.ReadDir(1); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// CertificateCheck returns a check which fails when there is no certificate,
+// or when one of them expires within the given duration.
+func CertificateCheck(certificates func() []*x509.Certificate, within time.Duration) Check {
+	return func(ctx context.Context) error {
+		certs := certificates()
+
+		if len(certs) == 0 {
+			return errors.New("no certificate is loaded")
+		}
+
+		deadline := time.Now().Add(within)
+
+		for _, cert := range certs {
+			if cert.NotAfter.Before(deadline) {
+				return fmt.Errorf("the certificate %q expires at %s", cert.Subject.String(), cert.NotAfter.Format(time.RFC3339))
+			}
+		}
+
+		return nil
+	}
+}
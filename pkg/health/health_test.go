@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func certificate(t *testing.T, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return cert
+}
+
+func TestCheckDir(t *testing.T) {
+	assert.NoError(t, CheckDir(t.TempDir()))
+	assert.Error(t, CheckDir(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestCertificateCheck(t *testing.T) {
+	ctx := context.Background()
+	week := 7 * 24 * time.Hour
+
+	valid := certificate(t, time.Now().Add(90*24*time.Hour))
+	expiring := certificate(t, time.Now().Add(24*time.Hour))
+
+	assert.NoError(t, CertificateCheck(func() []*x509.Certificate { return []*x509.Certificate{valid} }, week)(ctx))
+	assert.Error(t, CertificateCheck(func() []*x509.Certificate { return []*x509.Certificate{valid, expiring} }, week)(ctx))
+	assert.Error(t, CertificateCheck(func() []*x509.Certificate { return nil }, week)(ctx))
+}
+
+func TestReady(t *testing.T) {
+	ctx := context.Background()
+
+	Register("good", func(ctx context.Context) error { return nil })
+
+	results, ready := Ready(ctx)
+	assert.True(t, ready)
+	assert.Equal(t, StatusOK, results["good"])
+
+	Register("bad", func(ctx context.Context) error { return errors.New("unreachable") })
+
+	results, ready = Ready(ctx)
+	assert.False(t, ready)
+	assert.Equal(t, "unreachable", results["bad"])
+
+	Register("bad", func(ctx context.Context) error { return Warning{errors.New("stale")} })
+
+	results, ready = Ready(ctx)
+	assert.True(t, ready)
+	assert.Equal(t, "warning: stale", results["bad"])
+
+	Register("bad", func(ctx context.Context) error { return nil })
+	SetShuttingDown()
+
+	results, ready = Ready(ctx)
+	assert.False(t, ready)
+	assert.Contains(t, results, "shutdown")
+}
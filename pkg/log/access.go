@@ -0,0 +1,239 @@
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Access log formats
+const (
+	// FormatDefault the historical format of the access log
+	FormatDefault = "default"
+
+	// FormatCommon Apache Common Log Format
+	FormatCommon = "common"
+
+	// FormatCombined Apache Combined Log Format
+	FormatCombined = "combined"
+
+	// FormatJSON one json object per line
+	FormatJSON = "json"
+)
+
+const (
+	// UpstreamTimeKey is set, as a time.Duration, by the handlers which call an upstream server, see AddUpstreamTime
+	UpstreamTimeKey = "snowdream.tech/http-server/pkg/log/upstreamtimekey"
+)
+
+// AddUpstreamTime adds d to the time spent by the request calling the upstream servers,
+// eg: the OpenID provider. It is the UpstreamTime of the access log.
+func AddUpstreamTime(c *gin.Context, d time.Duration) {
+	if value, exists := c.Get(UpstreamTimeKey); exists {
+		if upstream, ok := value.(time.Duration); ok {
+			d += upstream
+		}
+	}
+
+	c.Set(UpstreamTimeKey, d)
+}
+
+// AccessLogEntry the fields of an access log line, usable in custom templates, eg:
+//
+//	{{.RemoteIP}} {{.User}} "{{.Method}} {{.Path}}" {{.Status}} {{.BytesSent}} {{.Latency}}
+type AccessLogEntry struct {
+	Time         time.Time `json:"time"`
+	RemoteIP     string    `json:"remote_ip"`
+	User         string    `json:"user,omitempty"`
+	RequestID    string    `json:"request_id,omitempty"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Proto        string    `json:"proto"`
+	Host         string    `json:"host"`
+	Status       int       `json:"status"`
+	BytesSent    int       `json:"bytes_sent"`
+	Latency      float64   `json:"latency_ms"`
+	Referer      string    `json:"referer,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	TLSVersion   string    `json:"tls_version,omitempty"`
+	UpstreamTime float64   `json:"upstream_time_ms,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// NewAccessLogEntry NewAccessLogEntry
+func NewAccessLogEntry(param gin.LogFormatterParams) AccessLogEntry {
+	entry := AccessLogEntry{
+		Time:      param.TimeStamp,
+		RemoteIP:  param.ClientIP,
+		Method:    param.Method,
+		Path:      param.Path,
+		Status:    param.StatusCode,
+		BytesSent: param.BodySize,
+		Latency:   float64(param.Latency) / float64(time.Millisecond),
+		Error:     strings.TrimSpace(param.ErrorMessage),
+	}
+
+	if entry.BytesSent < 0 {
+		entry.BytesSent = 0
+	}
+
+	if name, ok := param.Keys[gin.AuthUserKey].(string); ok {
+		entry.User = name
+	}
+
+	if upstream, ok := param.Keys[UpstreamTimeKey].(time.Duration); ok {
+		entry.UpstreamTime = float64(upstream) / float64(time.Millisecond)
+	}
+
+	if r := param.Request; r != nil {
+		entry.RequestID = requestID(param)
+		entry.Proto = r.Proto
+		entry.Host = r.Host
+		entry.Referer = r.Referer()
+		entry.UserAgent = r.UserAgent()
+
+		if r.TLS != nil {
+			entry.TLSVersion = tlsVersionName(r.TLS.Version)
+		}
+	}
+
+	return entry
+}
+
+// AccessLogFormatter returns the formatter of one of the named formats,
+// or of a text/template when format contains "{{".
+func AccessLogFormatter(format string) (gin.LogFormatter, error) {
+	switch format {
+	case "", FormatDefault:
+		return defaultFormatter, nil
+	case FormatCommon:
+		return func(param gin.LogFormatterParams) string {
+			return commonLogLine(NewAccessLogEntry(param)) + "\n"
+		}, nil
+	case FormatCombined:
+		return func(param gin.LogFormatterParams) string {
+			entry := NewAccessLogEntry(param)
+
+			return fmt.Sprintf("%s %q %q\n", commonLogLine(entry), dash(entry.Referer), dash(entry.UserAgent))
+		}, nil
+	case FormatJSON:
+		return func(param gin.LogFormatterParams) string {
+			line, err := json.Marshal(NewAccessLogEntry(param))
+
+			if err != nil {
+				return ""
+			}
+
+			return string(line) + "\n"
+		}, nil
+	}
+
+	if !strings.Contains(format, "{{") {
+		return nil, fmt.Errorf("unknown access log format %q, it should be default, common, combined, json or a template", format)
+	}
+
+	tmpl, err := template.New("access").Parse(format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(param gin.LogFormatterParams) string {
+		var buf bytes.Buffer
+
+		if err := tmpl.Execute(&buf, NewAccessLogEntry(param)); err != nil {
+			return err.Error() + "\n"
+		}
+
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+
+		return buf.String()
+	}, nil
+}
+
+func defaultFormatter(param gin.LogFormatterParams) string {
+	user := "-"
+
+	if name, ok := param.Keys[gin.AuthUserKey].(string); ok && name != "" {
+		user = name
+	}
+
+	// your custom format
+	return fmt.Sprintf("%s %s [%s] %s %s %s %s %d %s \"%s\" %s \n",
+		param.ClientIP,
+		user,
+		param.TimeStamp.Format(time.RFC1123),
+		requestID(param),
+		param.Method,
+		param.Path,
+		param.Request.Proto,
+		param.StatusCode,
+		param.Latency,
+		param.Request.UserAgent(),
+		param.ErrorMessage,
+	)
+}
+
+// commonLogLine %h %l %u %t "%r" %>s %b
+func commonLogLine(entry AccessLogEntry) string {
+	bytesSent := "-"
+
+	if entry.BytesSent > 0 {
+		bytesSent = fmt.Sprint(entry.BytesSent)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		entry.RemoteIP,
+		dash(entry.User),
+		entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method,
+		entry.Path,
+		entry.Proto,
+		entry.Status,
+		bytesSent,
+	)
+}
+
+// requestID the id set by the RequestID middleware, or the X-Request-ID header
+func requestID(param gin.LogFormatterParams) string {
+	if id, ok := param.Keys[RequestIDKey].(string); ok {
+		return id
+	}
+
+	if param.Request == nil {
+		return ""
+	}
+
+	return param.Request.Header.Get("X-Request-ID")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+
+	return fmt.Sprintf("0x%04X", version)
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testParams() gin.LogFormatterParams {
+	r, _ := http.NewRequest("GET", "https://example.com/files/a.zip", nil)
+	r.Header.Set("Referer", "https://example.com/files/")
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.Header.Set("X-Request-ID", "req-1")
+	r.TLS = &tls.ConnectionState{Version: tls.VersionTLS13}
+
+	return gin.LogFormatterParams{
+		Request:    r,
+		TimeStamp:  time.Date(2023, 12, 31, 23, 59, 58, 0, time.UTC),
+		StatusCode: 200,
+		Latency:    1500 * time.Microsecond,
+		ClientIP:   "10.0.0.1",
+		Method:     "GET",
+		Path:       "/files/a.zip",
+		BodySize:   1024,
+		Keys: map[string]any{
+			gin.AuthUserKey: "alice",
+			UpstreamTimeKey: 2 * time.Millisecond,
+		},
+	}
+}
+
+func TestAccessLogFormatterCommonAndCombined(t *testing.T) {
+	common, err := AccessLogFormatter(FormatCommon)
+	assert.NoError(t, err)
+	assert.Equal(t, `10.0.0.1 - alice [31/Dec/2023:23:59:58 +0000] "GET /files/a.zip HTTP/1.1" 200 1024`+"\n", common(testParams()))
+
+	combined, err := AccessLogFormatter(FormatCombined)
+	assert.NoError(t, err)
+	assert.Equal(t, `10.0.0.1 - alice [31/Dec/2023:23:59:58 +0000] "GET /files/a.zip HTTP/1.1" 200 1024 "https://example.com/files/" "curl/8.0"`+"\n", combined(testParams()))
+}
+
+func TestAccessLogFormatterJSON(t *testing.T) {
+	formatter, err := AccessLogFormatter(FormatJSON)
+	assert.NoError(t, err)
+
+	var fields map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(formatter(testParams())), &fields))
+
+	assert.Equal(t, "alice", fields["user"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, float64(1024), fields["bytes_sent"])
+	assert.Equal(t, float64(200), fields["status"])
+	assert.Equal(t, 1.5, fields["latency_ms"])
+	assert.Equal(t, 2.0, fields["upstream_time_ms"])
+	assert.Equal(t, "TLS 1.3", fields["tls_version"])
+}
+
+func TestAccessLogFormatterTemplate(t *testing.T) {
+	formatter, err := AccessLogFormatter(`{{.RemoteIP}} {{.Status}} {{.BytesSent}} {{.TLSVersion}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1 200 1024 TLS 1.3\n", formatter(testParams()))
+
+	_, err = AccessLogFormatter("unknown")
+	assert.Error(t, err)
+
+	_, err = AccessLogFormatter("{{.Broken")
+	assert.Error(t, err)
+}
+
+func TestAddUpstreamTime(t *testing.T) {
+	c, _ := gin.CreateTestContext(nil)
+
+	AddUpstreamTime(c, 2*time.Millisecond)
+	AddUpstreamTime(c, 3*time.Millisecond)
+
+	assert.Equal(t, 5*time.Millisecond, c.Keys[UpstreamTimeKey])
+}
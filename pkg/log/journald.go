@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// JournaldSocket the socket of the native protocol of systemd-journald
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// journaldWriter sends every write as one journal entry
+type journaldWriter struct {
+	conn   *net.UnixConn
+	fields []byte
+}
+
+func newJournaldWriter(facility int, priority int, identifier string) (*journaldWriter, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: JournaldSocket, Net: "unixgram"})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+
+	w := &journaldWriter{conn: conn}
+
+	w.fields = appendJournalField(w.fields, "PRIORITY", fmt.Sprint(priority))
+	w.fields = appendJournalField(w.fields, "SYSLOG_FACILITY", fmt.Sprint(facility))
+	w.fields = appendJournalField(w.fields, "SYSLOG_IDENTIFIER", identifier)
+
+	return w, nil
+}
+
+func (w *journaldWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\r\n")
+
+	if msg == "" {
+		return len(p), nil
+	}
+
+	entry := appendJournalField(append([]byte(nil), w.fields...), "MESSAGE", msg)
+
+	if _, err := w.conn.Write(entry); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// appendJournalField appends KEY=value, or the binary form when the value has several lines
+func appendJournalField(b []byte, key string, value string) []byte {
+	if !strings.Contains(value, "\n") {
+		return append(b, key+"="+value+"\n"...)
+	}
+
+	var size [8]byte
+
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+
+	var buf bytes.Buffer
+
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+
+	return append(b, buf.Bytes()...)
+}
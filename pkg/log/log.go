@@ -1,12 +1,12 @@
 package log
 
 import (
-	"io"
-	"log"
+	"errors"
+	"log/slog"
 	"os"
 
 	"github.com/gin-gonic/gin"
-	"gopkg.in/natefinch/lumberjack.v2"
+	"snowdream.tech/http-server/pkg/audit"
 	"snowdream.tech/http-server/pkg/configs"
 	"snowdream.tech/http-server/pkg/tools"
 )
@@ -16,45 +16,75 @@ func InitLoggerConfig() {
 	r := configs.GetAppConfig()
 	logDir := r.LogDir
 
+	// By default, debug messages are only shown in gin debug mode.
+	level := slog.LevelInfo
+
+	if gin.IsDebugging() {
+		level = slog.LevelDebug
+	}
+
+	var levelErr error
+
+	if r.LogLevel != "" {
+		level, levelErr = tools.ParseLogLevel(r.LogLevel)
+	}
+
+	tools.LogLevel().Set(level)
+
 	if _, err := os.Stat(logDir); err != nil {
 		err = os.MkdirAll(logDir, 0640)
 
 		if err != nil {
+			tools.Logger("log").Error("Failed to create the log directory", "dir", logDir, "error", err)
 			return
 		}
 	}
 
+	logConf := configs.GetLogConfig()
+
+	var streamErrs []error
+
 	// Set gin.log
-	ginlog := &lumberjack.Logger{
-		Filename:   logDir + "/gin.log",
-		MaxSize:    500, // megabytes
-		MaxBackups: 3,
-		MaxAge:     28,   //days
-		Compress:   true, // disabled by default
+	ginlog, err := OpenStream(logConf.Gin, PriorityInfo)
+	streamErrs = append(streamErrs, err)
+
+	tools.DefaultGinWriter = ginlog
+
+	// Set error.log
+	errorlog, err := OpenStream(logConf.Error, PriorityErr)
+	streamErrs = append(streamErrs, err)
+
+	tools.DefaultErrorWriter = errorlog
+
+	// Set audit.log
+	if r.AuditLog {
+		auditlog, err := OpenStream(logConf.Audit, PriorityInfo)
+		streamErrs = append(streamErrs, err)
+
+		audit.SetOutput(auditlog)
 	}
 
-	tools.DefaultGinWriter = io.MultiWriter(ginlog, os.Stdout)
-	log.SetOutput(tools.DefaultGinWriter)
-	log.SetPrefix("[LOG-debug] ")
+	// Every record goes to the gin stream, errors go to the error stream too.
+	tools.SetLogger(slog.New(tools.TeeHandler{
+		tools.NewLogHandler(r.LogFormat, tools.DefaultGinWriter),
+		tools.LevelHandler{Level: slog.LevelError, Handler: tools.NewLogHandler(r.LogFormat, tools.DefaultErrorWriter)},
+	}))
 
-	// gin.DefaultWriter = io.MultiWriter(accesslog)
-	// Use the following code if you need to write the logs to file and console at the same time.
-	gin.DefaultWriter = tools.DefaultGinWriter
+	if levelErr != nil {
+		tools.Logger("log").Warn("Invalid log level, info is used", "error", levelErr)
+	}
 
-	// Set error.log
-	errorlog := &lumberjack.Logger{
-		Filename:   logDir + "/error.log",
-		MaxSize:    500, // megabytes
-		MaxBackups: 3,
-		MaxAge:     28,   //days
-		Compress:   true, // disabled by default
+	if err := errors.Join(streamErrs...); err != nil {
+		tools.Logger("log").Warn("Some log destinations are not available", "error", err)
 	}
 
-	// gin.DefaultErrorWriter = io.MultiWriter(errorlog)
-	// Use the following code if you need to write the logs to file and console at the same time.
-	gin.DefaultErrorWriter = io.MultiWriter(errorlog, os.Stderr)
+	reopenOnSignal()
+
+	// Route the output of gin through the logger.
+	gin.DefaultWriter = tools.LogWriter("gin", slog.LevelDebug)
+	gin.DefaultErrorWriter = tools.LogWriter("gin", slog.LevelError)
 
 	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
-		tools.DebugPrintF("[INFO] %-6s %-25s --> %s (%d handlers)", httpMethod, absolutePath, handlerName, nuHandlers)
+		tools.Logger("gin").Debug("Route", "method", httpMethod, "path", absolutePath, "handler", handlerName, "handlers", nuHandlers)
 	}
 }
@@ -0,0 +1,27 @@
+package log
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+const (
+	// RequestIDKey the id of the request, set by the RequestID middleware
+	RequestIDKey = "snowdream.tech/http-server/pkg/log/requestidkey"
+
+	// LoggerKey the logger of the request, with its id
+	LoggerKey = "snowdream.tech/http-server/pkg/log/loggerkey"
+)
+
+// Default returns the logger of the request, eg: log.Default(c).Info("...")
+func Default(c *gin.Context) *slog.Logger {
+	if value, exists := c.Get(LoggerKey); exists {
+		if logger, ok := value.(*slog.Logger); ok {
+			return logger
+		}
+	}
+
+	return tools.Logger("request")
+}
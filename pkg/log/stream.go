@@ -0,0 +1,182 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/env"
+)
+
+// Severities of the syslog and journald records, as defined by RFC 5424
+const (
+	PriorityErr  = 3
+	PriorityInfo = 6
+)
+
+// facilities the syslog facilities, as defined by RFC 5424
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var (
+	filesMu sync.Mutex
+
+	// files the opened log files, they are reopened by Reopen
+	files []*lumberjack.Logger
+)
+
+// OpenStream returns the writer of a log stream, which writes to its file,
+// the console, syslog and journald, as configured.
+// The priority is the severity of its syslog and journald records.
+func OpenStream(conf configs.LogStreamConfig, priority int) (io.Writer, error) {
+	var writers []io.Writer
+	var errs []error
+
+	if conf.File != "" {
+		filename := conf.File
+
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(configs.GetAppConfig().LogDir, filename)
+		}
+
+		writers = append(writers, openFile(filename, conf))
+	}
+
+	switch conf.Console {
+	case "stdout":
+		writers = append(writers, os.Stdout)
+	case "stderr":
+		writers = append(writers, os.Stderr)
+	case "", "none":
+	default:
+		errs = append(errs, fmt.Errorf("unknown console %q, it should be stdout, stderr or none", conf.Console))
+	}
+
+	logConf := configs.GetLogConfig()
+
+	tag := logConf.SyslogTag
+
+	if tag == "" {
+		tag = env.ProjectName
+	}
+
+	facility, ok := facilities[logConf.SyslogFacility]
+
+	if !ok {
+		facility = facilities["daemon"]
+	}
+
+	if conf.Syslog {
+		w, err := newSyslogWriter(facility, priority, tag)
+
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			writers = append(writers, w)
+		}
+	}
+
+	if conf.Journald {
+		w, err := newJournaldWriter(facility, priority, tag)
+
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			writers = append(writers, w)
+		}
+	}
+
+	return teeWriter(writers), errors.Join(errs...)
+}
+
+// teeWriter writes to every destination, even if some of them fail,
+// eg: syslog is down, the file and the console still get the lines.
+type teeWriter []io.Writer
+
+// Write returns the errors of the failed destinations, joined
+func (t teeWriter) Write(p []byte) (int, error) {
+	var errs []error
+
+	for _, w := range t {
+		n, err := w.Write(p)
+
+		if err == nil && n != len(p) {
+			err = io.ErrShortWrite
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(p), errors.Join(errs...)
+}
+
+// Reopen closes the log files, they are reopened by the next write.
+// It lets external tools, such as logrotate, move the files away.
+func Reopen() {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+func openFile(filename string, conf configs.LogStreamConfig) *lumberjack.Logger {
+	f := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    conf.MaxSize, // megabytes
+		MaxBackups: conf.MaxBackups,
+		MaxAge:     conf.MaxAge, //days
+		Compress:   conf.Compress,
+	}
+
+	filesMu.Lock()
+	files = append(files, f)
+	filesMu.Unlock()
+
+	if conf.Daily {
+		go rotateDaily(f)
+	}
+
+	return f
+}
+
+// rotateDaily rotates the file at midnight
+func rotateDaily(f *lumberjack.Logger) {
+	for {
+		now := time.Now()
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+		time.Sleep(time.Until(midnight))
+
+		f.Rotate()
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	"errors"
+	"io"
+)
+
+// newSyslogWriter syslog is not supported on this platform
+func newSyslogWriter(facility int, priority int, tag string) (io.Writer, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+// reopenOnSignal there is no SIGUSR1 on this platform
+func reopenOnSignal() {
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+func TestOpenStreamReopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+
+	w, err := OpenStream(configs.LogStreamConfig{File: filename, MaxSize: 1, Console: "none"}, PriorityInfo)
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	// an external rotator moves the file away
+	assert.NoError(t, os.Rename(filename, filename+".1"))
+
+	Reopen()
+
+	_, err = w.Write([]byte("second\n"))
+	assert.NoError(t, err)
+
+	rotated, _ := os.ReadFile(filename + ".1")
+	assert.Equal(t, "first\n", string(rotated))
+
+	current, _ := os.ReadFile(filename)
+	assert.Equal(t, "second\n", string(current))
+
+	_, err = OpenStream(configs.LogStreamConfig{Console: "printer"}, PriorityInfo)
+	assert.Error(t, err)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("socket is down")
+}
+
+func TestTeeWriterWritesToEveryDestination(t *testing.T) {
+	var first, last bytes.Buffer
+
+	w := teeWriter{&first, failingWriter{}, &last, failingWriter{}}
+
+	n, err := w.Write([]byte("line\n"))
+	assert.Equal(t, 5, n)
+	assert.ErrorContains(t, err, "socket is down")
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+
+	assert.Equal(t, "line\n", first.String())
+	assert.Equal(t, "line\n", last.String())
+
+	_, err = teeWriter{&first, io.Discard}.Write([]byte("ok\n"))
+	assert.NoError(t, err)
+}
+
+func TestAppendJournalField(t *testing.T) {
+	assert.Equal(t, "MESSAGE=hello\n", string(appendJournalField(nil, "MESSAGE", "hello")))
+
+	assert.Equal(t, "MESSAGE\n\x05\x00\x00\x00\x00\x00\x00\x00a\nbcd\n", string(appendJournalField(nil, "MESSAGE", "a\nbcd")))
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"io"
+	"log/syslog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// newSyslogWriter connects to the local syslog daemon
+func newSyslogWriter(facility int, priority int, tag string) (io.Writer, error) {
+	return syslog.New(syslog.Priority(facility<<3|priority), tag)
+}
+
+// reopenOnSignal reopens the log files on SIGUSR1
+func reopenOnSignal() {
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, syscall.SIGUSR1)
+
+	go func() {
+		for range signals {
+			Reopen()
+
+			tools.Logger("log").Info("The log files are reopened")
+		}
+	}()
+}
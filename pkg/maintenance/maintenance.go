@@ -0,0 +1,39 @@
+package maintenance
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// State the maintenance mode of the server
+type State struct {
+	Enable  bool      `json:"enable" xml:"enable" yaml:"enable"`
+	Message string    `json:"message,omitempty" xml:"message,omitempty" yaml:"message,omitempty"`
+	Since   time.Time `json:"since" xml:"since" yaml:"since"`
+}
+
+var state atomic.Pointer[State]
+
+func init() {
+	state.Store(&State{})
+}
+
+// Get returns the current state
+func Get() State {
+	return *state.Load()
+}
+
+// Set enables, or disables, the maintenance mode.
+// While it is enabled, the requests are answered with 503 and the message.
+func Set(enable bool, message string) State {
+	s := &State{Enable: enable}
+
+	if enable {
+		s.Message = message
+		s.Since = time.Now()
+	}
+
+	state.Store(s)
+
+	return *s
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace the prefix of the metrics
+const Namespace = "http_server"
+
+// Limiters
+const (
+	// LimiterRequests the requests rate limiter
+	LimiterRequests = "requests"
+
+	// LimiterBruteForce the brute-force protection
+	LimiterBruteForce = "bruteforce"
+)
+
+var (
+	// Registry the registry of the metrics, with the Go runtime and process metrics
+	Registry = prometheus.NewRegistry()
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "requests_total",
+		Help:      "The number of requests, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "request_duration_seconds",
+		Help:      "The latency of the requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	bytesServed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "response_bytes_total",
+		Help:      "The bytes of the response bodies, by mount.",
+	}, []string{"mount"})
+
+	activeConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "active_connections",
+		Help:      "The number of open client connections.",
+	})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "rate_limited_total",
+		Help:      "The number of requests rejected by a limiter.",
+	}, []string{"limiter"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "auth_failures_total",
+		Help:      "The number of failed authentications, by method.",
+	}, []string{"method"})
+
+	compressionRatio = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "compression_ratio",
+		Help:      "The compressed size of the responses divided by their uncompressed size.",
+		Buckets:   []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		requests,
+		duration,
+		bytesServed,
+		activeConnections,
+		rateLimited,
+		authFailures,
+		compressionRatio,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRequest records a request. The route is the pattern of the route,
+// eg: /*filepath, the mount is the route without its wildcard.
+func ObserveRequest(method string, route string, status int, latency time.Duration, size int) {
+	code := strconv.Itoa(status)
+
+	if route == "" {
+		route = "unmatched"
+	}
+
+	requests.WithLabelValues(method, route, code).Inc()
+	duration.WithLabelValues(method, route, code).Observe(latency.Seconds())
+
+	if size > 0 && route != "unmatched" {
+		bytesServed.WithLabelValues(Mount(route)).Add(float64(size))
+	}
+}
+
+// Mount returns the route without its wildcard, eg: /files/*filepath is /files/
+func Mount(route string) string {
+	if i := strings.IndexAny(route, "*:"); i >= 0 {
+		route = route[:i]
+	}
+
+	if route == "" {
+		return "/"
+	}
+
+	return route
+}
+
+// ConnState counts the active connections, it is a http.Server ConnState hook
+func ConnState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		activeConnections.Inc()
+	case http.StateHijacked, http.StateClosed:
+		activeConnections.Dec()
+	}
+}
+
+// RateLimited records a request rejected by a limiter
+func RateLimited(limiter string) {
+	rateLimited.WithLabelValues(limiter).Inc()
+}
+
+// AuthFailure records a failed authentication
+func AuthFailure(method string) {
+	authFailures.WithLabelValues(method).Inc()
+}
+
+// ObserveCompression records the sizes of a compressed response
+func ObserveCompression(uncompressed int, compressed int) {
+	if uncompressed <= 0 || compressed <= 0 {
+		return
+	}
+
+	compressionRatio.Observe(float64(compressed) / float64(uncompressed))
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMount(t *testing.T) {
+	assert.Equal(t, "/", Mount("/*filepath"))
+	assert.Equal(t, "/files/", Mount("/files/*filepath"))
+	assert.Equal(t, "/users/", Mount("/users/:id"))
+	assert.Equal(t, "/metrics", Mount("/metrics"))
+}
+
+func TestObserveRequest(t *testing.T) {
+	ObserveRequest("GET", "/files/*filepath", 200, 10*time.Millisecond, 100)
+	ObserveRequest("GET", "/files/*filepath", 200, 20*time.Millisecond, 50)
+	ObserveRequest("GET", "", 404, time.Millisecond, 10)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues("GET", "/files/*filepath", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 150.0, testutil.ToFloat64(bytesServed.WithLabelValues("/files/")))
+
+	expected := `
+# HELP http_server_rate_limited_total The number of requests rejected by a limiter.
+# TYPE http_server_rate_limited_total counter
+http_server_rate_limited_total{limiter="requests"} 1
+`
+	RateLimited(LimiterRequests)
+
+	assert.NoError(t, testutil.GatherAndCompare(Registry, strings.NewReader(expected), "http_server_rate_limited_total"))
+}
@@ -19,8 +19,12 @@ import (
 
 	"github.com/docker/go-units"
 	"github.com/juju/ratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/downloads"
 	"snowdream.tech/http-server/pkg/io"
+	"snowdream.tech/http-server/pkg/tracing"
 )
 
 // condResult is the result of an HTTP request precondition check.
@@ -115,7 +119,7 @@ func (f *fileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	default:
 		w.Header().Set("Allow", options)
-		http.Error(w, "read-only", http.StatusMethodNotAllowed)
+		serveError(w, "read-only", http.StatusMethodNotAllowed)
 	}
 }
 
@@ -137,18 +141,22 @@ func serveFile(w http.ResponseWriter, r *http.Request, fs http.FileSystem, name
 		return
 	}
 
+	_, span := tracing.Start(r.Context(), "fs.open", trace.WithAttributes(attribute.String("file.path", name)))
 	f, err := fs.Open(name)
+	tracing.End(span, err)
 	if err != nil {
 		msg, code := toHTTPError(err)
-		http.Error(w, msg, code)
+		serveError(w, msg, code)
 		return
 	}
 	defer f.Close()
 
+	_, span = tracing.Start(r.Context(), "fs.stat", trace.WithAttributes(attribute.String("file.path", name)))
 	d, err := f.Stat()
+	tracing.End(span, err)
 	if err != nil {
 		msg, code := toHTTPError(err)
-		http.Error(w, msg, code)
+		serveError(w, msg, code)
 		return
 	}
 
@@ -201,12 +209,19 @@ func serveFile(w http.ResponseWriter, r *http.Request, fs http.FileSystem, name
 		return
 	}
 
+	ServeContent(w, r, d.Name(), d.ModTime(), f)
+}
+
+// ServeContent serves the content of a file, at SpeedLimiter bytes per second at most
+func ServeContent(w http.ResponseWriter, r *http.Request, name string, modtime time.Time, f http.File) {
+	app := configs.GetAppConfig()
+
 	if app.SpeedLimiter <= 0 {
-		http.ServeContent(w, r, d.Name(), d.ModTime(), f)
+		http.ServeContent(w, r, name, modtime, f)
 	} else {
 		bucket := ratelimit.NewBucketWithRate(float64(app.SpeedLimiter), app.SpeedLimiter)
 		readseeker := io.ReadSeeker(f, f, bucket)
-		http.ServeContent(w, r, d.Name(), d.ModTime(), readseeker)
+		http.ServeContent(w, r, name, modtime, readseeker)
 	}
 }
 
@@ -216,6 +231,7 @@ func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
 	// Stat on every entry of a directory on Unix.
 	var dirs anyDirs
 	var err error
+	_, span := tracing.Start(r.Context(), "fs.readdir")
 	if d, ok := f.(fs.ReadDirFile); ok {
 		var list dirEntryDirs
 		list, err = d.ReadDir(-1)
@@ -225,10 +241,14 @@ func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
 		list, err = f.Readdir(-1)
 		dirs = list
 	}
+	if err == nil {
+		span.SetAttributes(attribute.Int("fs.entries", dirs.len()))
+	}
+	tracing.End(span, err)
 
 	if err != nil {
 		//logf(r, "http: error reading directory: %v", err)
-		http.Error(w, "http.Error reading directory", http.StatusInternalServerError)
+		serveError(w, "http.Error reading directory", http.StatusInternalServerError)
 		return
 	}
 	sort.Slice(dirs, func(i, j int) bool { return dirs.name(i) < dirs.name(j) })
@@ -240,6 +260,25 @@ func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
 		timeformat = app.AutoIndexTimeFormat
 	}
 
+	// the download counts recorded in the database
+	var counts map[string]downloads.Count
+
+	if store := downloads.Default(); app.AutoIndexDownloads && store != nil {
+		paths := make([]string, 0, dirs.len())
+
+		for i, n := 0, dirs.len(); i < n; i++ {
+			if !dirs.isDir(i) {
+				paths = append(paths, path.Join("/", r.URL.Path, dirs.name(i)))
+			}
+		}
+
+		counts, err = store.Counts(r.Context(), paths...)
+
+		if err != nil {
+			counts = map[string]downloads.Count{}
+		}
+	}
+
 	title := fmt.Sprintf("Index of %s", r.URL)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -260,6 +299,7 @@ func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
 	fmt.Fprintf(w, ".item-file %s\n", "{display: flex; flex-grow: 6; min-width:600px;max-width:600px; overflow:hidden;text-overflow:ellipsis;white-space:nowrap}")
 	fmt.Fprintf(w, ".item-time %s\n", "{display: flex; flex-grow: 3; min-width:300px;max-width:300px;}")
 	fmt.Fprintf(w, ".item-size %s\n", "{display: flex; flex-grow: 2; min-width:200px;max-width:200px;}")
+	fmt.Fprintf(w, ".item-downloads %s\n", "{display: flex; flex-grow: 1; min-width:100px;max-width:100px;}")
 	fmt.Fprintf(w, ".header %s\n", "{display: flex;flex-direction: column; justify-content: flex-start;align-items: flex-start;flex: 0 0 auto;}")
 	fmt.Fprintf(w, ".content %s\n", "{display: flex;flex-direction: column; justify-content: flex-start;align-items: flex-start;flex: 1 0 auto;}")
 	fmt.Fprintf(w, ".footer %s\n", "{display: flex; justify-content: center;align-items: center;flex-direction: row; flex: 0 0 auto; padding-bottom:10px;'}")
@@ -304,6 +344,14 @@ func dirList(w http.ResponseWriter, r *http.Request, f http.File) {
 			}
 		}
 
+		if counts != nil {
+			if dirs.isDir(i) {
+				fmt.Fprintf(w, "<span class=\"item-downloads\">%s</span>\n", "-")
+			} else {
+				fmt.Fprintf(w, "<span class=\"item-downloads\">%d</span>\n", counts[path.Join("/", r.URL.Path, dirs.name(i))].Downloads)
+			}
+		}
+
 		fmt.Fprintf(w, "</item>\n")
 	}
 	// fmt.Fprintf(w, "</pre>\n")
@@ -327,6 +375,15 @@ func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
 	w.WriteHeader(http.StatusMovedPermanently)
 }
 
+// serveError replies like http.Error, with the id of the request
+func serveError(w http.ResponseWriter, msg string, code int) {
+	if id := w.Header().Get(configs.GetRequestIDConfig().Header); id != "" {
+		msg += "\nRequest ID: " + id
+	}
+
+	http.Error(w, msg, code)
+}
+
 // toHTTPError returns a non-specific HTTP error message and status code
 // for a given non-nil error value. It's important that toHTTPError does not
 // actually return err.http.Error(), since msg and http.Status are returned to users,
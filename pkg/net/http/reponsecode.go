@@ -10,6 +10,9 @@ const (
 	//StatusUnauthorized Status Unauthorized
 	StatusUnauthorized = "StatusUnauthorized"
 
+	//StatusForbidden Status Forbidden
+	StatusForbidden = "StatusForbidden"
+
 	//TooManyRequests Too Many Requests
 	TooManyRequests = "TooManyRequests"
 
@@ -3,6 +3,7 @@ package http
 import (
 	"time"
 
+	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"snowdream.tech/http-server/pkg/i18n"
 )
@@ -38,13 +39,22 @@ func NewResponse(code string, message string, data any) Response {
 	}
 }
 
+// newContextResponse NewResponse with the id of the request
+func newContextResponse(c *gin.Context, code string, message string, data any) Response {
+	response := NewResponse(code, message, data)
+
+	response.RequestID = requestid.Get(c)
+
+	return response
+}
+
 // ResponseSuccess ResponseSuccess
 func ResponseSuccess(c *gin.Context) Response {
 	i18 := i18n.Default(c)
 
 	str := i18.T(c, "SUCCESS")
 
-	return NewResponse("SUCCESS", str, nil)
+	return newContextResponse(c, "SUCCESS", str, nil)
 }
 
 // ResponseSuccessWithMessage ResponseSuccessWithMessage
@@ -61,7 +71,7 @@ func ResponseSuccessWithMessage(c *gin.Context, key string, params ...string) Re
 
 	str := i18.T(c, key, paramarrs...)
 
-	return NewResponse("SUCCESS", str, nil)
+	return newContextResponse(c, "SUCCESS", str, nil)
 }
 
 // ResponseSuccessWithData ResponseSuccessWithData
@@ -70,7 +80,7 @@ func ResponseSuccessWithData(c *gin.Context, data any) Response {
 
 	str := i18.T(c, "SUCCESS")
 
-	return NewResponse("SUCCESS", str, data)
+	return newContextResponse(c, "SUCCESS", str, data)
 }
 
 // ResponseSuccessWithMessageAndData ResponseSuccessWithMessageAndData
@@ -87,7 +97,7 @@ func ResponseSuccessWithMessageAndData(c *gin.Context, data any, key string, par
 
 	str := i18.T(c, key, paramarrs...)
 
-	return NewResponse("SUCCESS", str, data)
+	return newContextResponse(c, "SUCCESS", str, data)
 }
 
 // ResponseFailure ResponseFailure
@@ -96,7 +106,7 @@ func ResponseFailure(c *gin.Context) Response {
 
 	str := i18.T(c, "FAILURE")
 
-	return NewResponse("FAILURE", str, nil)
+	return newContextResponse(c, "FAILURE", str, nil)
 }
 
 // ResponseFailureWithMessage ResponseFailureWithMessage
@@ -113,7 +123,7 @@ func ResponseFailureWithMessage(c *gin.Context, key string, params ...string) Re
 
 	str := i18.T(c, key, paramarrs...)
 
-	return NewResponse("FAILURE", str, nil)
+	return newContextResponse(c, "FAILURE", str, nil)
 }
 
 // ResponseFailureWithData ResponseFailureWithData
@@ -122,7 +132,7 @@ func ResponseFailureWithData(c *gin.Context, data any) Response {
 
 	str := i18.T(c, "FAILURE")
 
-	return NewResponse("FAILURE", str, data)
+	return newContextResponse(c, "FAILURE", str, data)
 }
 
 // ResponseFailureWithMessageAndData ResponseFailureWithMessageAndData
@@ -139,5 +149,5 @@ func ResponseFailureWithMessageAndData(c *gin.Context, data any, key string, par
 
 	str := i18.T(c, key, paramarrs...)
 
-	return NewResponse("FAILURE", str, data)
+	return newContextResponse(c, "FAILURE", str, data)
 }
@@ -6,10 +6,12 @@ package http
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 	"path"
 	"strings"
 
+	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"snowdream.tech/http-server/pkg/env"
 )
@@ -89,6 +91,12 @@ func createStaticHandler(group *gin.RouterGroup, relativePath string, fs http.Fi
 			fmt.Fprintf(w, "<center>\n")
 			fmt.Fprintf(w, "%s/%s\n", env.ProjectName, env.GitTag)
 			fmt.Fprintf(w, "</center>\n")
+
+			if id := requestid.Get(c); id != "" {
+				fmt.Fprintf(w, "<center>\n")
+				fmt.Fprintf(w, "Request ID: %s\n", html.EscapeString(id))
+				fmt.Fprintf(w, "</center>\n")
+			}
 			fmt.Fprintf(w, "</div>\n")
 			fmt.Fprintf(w, "<div class=\"footer\">\n")
 			fmt.Fprintf(w, "Powered by")
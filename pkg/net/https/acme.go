@@ -0,0 +1,94 @@
+package https
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/env"
+)
+
+// NewACMEManager returns the manager which obtains and renews the certificates of the domains.
+// Its HTTPHandler answers the http-01 challenges, and its TLSConfig the tls-alpn-01 ones.
+func NewACMEManager(conf *configs.ACMEConfig) (*autocert.Manager, error) {
+	if len(conf.Domains) == 0 {
+		return nil, errors.New("acme requires at least one domain")
+	}
+
+	cacheDir := conf.CacheDir
+
+	if cacheDir == "" {
+		cacheDir = "certs"
+	}
+
+	directoryURL := conf.DirectoryURL
+
+	if directoryURL == "" {
+		directoryURL = autocert.DefaultACMEDirectory
+	}
+
+	client := &acme.Client{
+		DirectoryURL: directoryURL,
+		UserAgent:    env.ProjectName,
+	}
+
+	if conf.CAFile != "" {
+		data, err := os.ReadFile(conf.CAFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificate found in " + conf.CAFile)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+		client.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	whitelist := autocert.HostWhitelist(conf.Domains...)
+
+	manager := &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		// The http-01 challenges are checked against the Host header,
+		// which has a port when the http server is not on port 80.
+		HostPolicy: func(ctx context.Context, host string) error {
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+
+			return whitelist(ctx, host)
+		},
+		Cache:       autocert.DirCache(cacheDir),
+		Email:       conf.Email,
+		RenewBefore: time.Duration(conf.RenewBefore) * time.Second,
+		Client:      client,
+	}
+
+	if conf.EABKeyID != "" {
+		key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(conf.EABHMACKey, "="))
+
+		if err != nil || len(key) == 0 {
+			return nil, errors.New("the eab hmac key should be base64url encoded")
+		}
+
+		manager.ExternalAccountBinding = &acme.ExternalAccountBinding{KID: conf.EABKeyID, Key: key}
+	}
+
+	return manager, nil
+}
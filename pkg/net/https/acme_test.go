@@ -0,0 +1,129 @@
+package https
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/letsencrypt/challtestsrv"
+	"github.com/letsencrypt/pebble/v2/ca"
+	"github.com/letsencrypt/pebble/v2/db"
+	"github.com/letsencrypt/pebble/v2/va"
+	"github.com/letsencrypt/pebble/v2/wfe"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+// pebble runs the Pebble ACME test server in process. It requires an External Account Binding,
+// resolves every name to 127.0.0.1, and validates the challenges on the given ports.
+func pebble(t *testing.T, httpPort int, tlsPort int, eabKeyID string, eabKey string) (directoryURL string, caFile string) {
+	t.Setenv("PEBBLE_VA_NOSLEEP", "1")
+
+	logger := log.New(io.Discard, "", 0)
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	dnsAddr := udp.LocalAddr().String()
+	udp.Close()
+
+	dns, err := challtestsrv.New(challtestsrv.Config{DNSOneAddrs: []string{dnsAddr}, Log: logger})
+	assert.NoError(t, err)
+
+	dns.SetDefaultDNSIPv6("")
+
+	go dns.Run()
+	t.Cleanup(dns.Shutdown)
+
+	store := db.NewMemoryStore()
+	assert.NoError(t, store.AddExternalAccountKeyByID(eabKeyID, eabKey))
+
+	authority := ca.New(logger, store, "", 0, 1, 0)
+	validator := va.New(logger, httpPort, tlsPort, false, dnsAddr)
+
+	frontend := wfe.New(logger, store, validator, authority, false, true)
+
+	server := httptest.NewTLSServer(frontend.Handler())
+	t.Cleanup(server.Close)
+
+	caFile = filepath.Join(t.TempDir(), "pebble.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	return server.URL + wfe.DirectoryPath, caFile
+}
+
+func listen(t *testing.T) (net.Listener, int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { ln.Close() })
+
+	return ln, ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestACMEManager(t *testing.T) {
+	_, err := NewACMEManager(&configs.ACMEConfig{})
+	assert.Error(t, err)
+
+	_, err = NewACMEManager(&configs.ACMEConfig{Domains: []string{"example.test"}, EABKeyID: "kid", EABHMACKey: "%%"})
+	assert.Error(t, err)
+
+	eabKey := base64.RawURLEncoding.EncodeToString([]byte("a-secret-hmac-key-of-32-bytes!!!"))
+
+	for _, challenge := range []string{"tls-alpn-01", "http-01"} {
+		t.Run(challenge, func(t *testing.T) {
+			httpLn, httpPort := listen(t)
+			tlsLn, tlsPort := listen(t)
+
+			directoryURL, caFile := pebble(t, httpPort, tlsPort, "kid-1", eabKey)
+
+			manager, err := NewACMEManager(&configs.ACMEConfig{
+				Domains:      []string{"example.test"},
+				Email:        "admin@example.test",
+				CacheDir:     t.TempDir(),
+				DirectoryURL: directoryURL,
+				CAFile:       caFile,
+				EABKeyID:     "kid-1",
+				EABHMACKey:   eabKey,
+			})
+			assert.NoError(t, err)
+
+			go http.Serve(httpLn, manager.HTTPHandler(nil))
+
+			if challenge == "tls-alpn-01" {
+				go http.Serve(tls.NewListener(tlsLn, &tls.Config{
+					GetCertificate: manager.GetCertificate,
+					NextProtos:     []string{"http/1.1", acme.ALPNProto},
+				}), http.NotFoundHandler())
+			} else {
+				// the tls-alpn-01 validation fails, autocert falls back to http-01
+				tlsLn.Close()
+			}
+
+			cert, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.test"})
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, []string{"example.test"}, cert.Leaf.DNSNames)
+
+			// the certificate is cached
+			again, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.test"})
+			assert.NoError(t, err)
+			assert.Equal(t, cert.Leaf.SerialNumber, again.Leaf.SerialNumber)
+
+			_, err = manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.test"})
+			assert.Error(t, err)
+		})
+	}
+}
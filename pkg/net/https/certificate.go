@@ -0,0 +1,189 @@
+package https
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// WatchDelay the files are reloaded once they have not changed for this delay,
+// so that the certificate and the key are renewed together.
+var WatchDelay = time.Second
+
+// Certificate a certificate loaded from a pair of files, which can be reloaded
+// while the server is running.
+type Certificate struct {
+	CertFile string
+	KeyFile  string
+
+	cert atomic.Pointer[tls.Certificate]
+	ocsp atomic.Pointer[OCSPStatus]
+
+	// reloaded wakes up the OCSP stapling
+	reloaded chan struct{}
+}
+
+var (
+	mu           sync.Mutex
+	certificates []*Certificate
+)
+
+// LoadCertificate loads the certificate, and registers it for ReloadCertificates
+func LoadCertificate(certFile string, keyFile string) (*Certificate, error) {
+	c := &Certificate{CertFile: certFile, KeyFile: keyFile, reloaded: make(chan struct{}, 1)}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	certificates = append(certificates, c)
+
+	return c, nil
+}
+
+// Reload loads the files again, the current certificate is kept when they are invalid
+func (c *Certificate) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+
+	if err != nil {
+		return err
+	}
+
+	if len(cert.Certificate) > 0 {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+
+		if err != nil {
+			return err
+		}
+
+		cert.Leaf = leaf
+
+		tools.Logger("https").Info("The certificate has been loaded", "file", c.CertFile,
+			"subject", leaf.Subject.String(), "expiry", leaf.NotAfter)
+	}
+
+	c.cert.Store(&cert)
+
+	select {
+	case c.reloaded <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Certificate returns the current certificate
+func (c *Certificate) Certificate() *tls.Certificate {
+	return c.cert.Load()
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Watch reloads the certificate when its files change, until the watcher is closed.
+// The directories are watched, so that the files can be replaced by a rename,
+// or by swapping a symlink like kubernetes does for secrets.
+func (c *Certificate) Watch() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range []string{filepath.Dir(c.CertFile), filepath.Dir(c.KeyFile)} {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	go c.watch(watcher)
+
+	return watcher, nil
+}
+
+func (c *Certificate) watch(watcher *fsnotify.Watcher) {
+	logger := tools.Logger("https")
+
+	var timer *time.Timer
+
+	reload := func() {
+		if err := c.Reload(); err != nil {
+			logger.Error("Failed to reload the certificate, the current one is kept", "file", c.CertFile, "error", err)
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+
+				return
+			}
+
+			if !c.affectedBy(event) {
+				continue
+			}
+
+			if timer == nil {
+				timer = time.AfterFunc(WatchDelay, reload)
+			} else {
+				timer.Reset(WatchDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			logger.Warn("Failed to watch the certificate", "file", c.CertFile, "error", err)
+		}
+	}
+}
+
+// affectedBy reports whether the event may change the files of the certificate
+func (c *Certificate) affectedBy(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	name := filepath.Clean(event.Name)
+
+	if name == filepath.Clean(c.CertFile) || name == filepath.Clean(c.KeyFile) {
+		return true
+	}
+
+	// kubernetes swaps the ..data symlink of the secret volume
+	return filepath.Base(name) == "..data"
+}
+
+// ReloadCertificates reloads all the loaded certificates
+func ReloadCertificates() error {
+	mu.Lock()
+	list := append([]*Certificate(nil), certificates...)
+	mu.Unlock()
+
+	var errs []error
+
+	for _, c := range list {
+		if err := c.Reload(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
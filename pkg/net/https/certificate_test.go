@@ -0,0 +1,76 @@
+package https
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate for name, and its key
+func writeCertificate(t *testing.T, certFile string, keyFile string, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	// replaced by a rename, like most renewal tools do
+	for file, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		assert.NoError(t, os.WriteFile(file+".tmp", pem.EncodeToMemory(block), 0600))
+		assert.NoError(t, os.Rename(file+".tmp", file))
+	}
+}
+
+func TestCertificateWatch(t *testing.T) {
+	WatchDelay = 100 * time.Millisecond
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.pem")
+	keyFile := filepath.Join(dir, "server.key")
+
+	writeCertificate(t, certFile, keyFile, "old.example.com")
+
+	certificate, err := LoadCertificate(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "old.example.com", certificate.Certificate().Leaf.Subject.CommonName)
+
+	watcher, err := certificate.Watch()
+	assert.NoError(t, err)
+	defer watcher.Close()
+
+	writeCertificate(t, certFile, keyFile, "new.example.com")
+
+	assert.Eventually(t, func() bool {
+		cert, _ := certificate.GetCertificate(nil)
+		return cert.Leaf.Subject.CommonName == "new.example.com"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// an invalid key keeps the current certificate
+	assert.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0600))
+	assert.Error(t, ReloadCertificates())
+	assert.Equal(t, "new.example.com", certificate.Certificate().Leaf.Subject.CommonName)
+}
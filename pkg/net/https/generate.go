@@ -0,0 +1,257 @@
+package https
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"snowdream.tech/http-server/pkg/env"
+	gos "snowdream.tech/http-server/pkg/os"
+)
+
+// Validity of the generated certificates
+const (
+	// CAValidity the validity of a local CA
+	CAValidity = 10 * 365 * 24 * time.Hour
+
+	// ServerValidity the validity of a server certificate, the maximum accepted by the browsers
+	ServerValidity = 397 * 24 * time.Hour
+)
+
+// KeyPair a certificate and its private key
+type KeyPair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// GenerateCA creates the certificate and the key of a local CA
+func GenerateCA(commonName string, validity time.Duration) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(commonName, validity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return sign(template, key, nil)
+}
+
+// GenerateCertificate creates a server certificate for the hostnames and the ips,
+// issued by the CA, or self-signed when it is nil
+func GenerateCertificate(hosts []string, validity time.Duration, ca *KeyPair) (*KeyPair, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("at least one hostname or ip is required")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(hosts[0], validity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	template.BasicConstraintsValid = true
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return sign(template, key, ca)
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		// tolerate the clock skew of the clients
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(validity),
+	}
+
+	// the project name is set when it is built by the Makefile
+	if env.ProjectName != "" {
+		template.Subject.Organization = []string{env.ProjectName}
+	}
+
+	return template, nil
+}
+
+func sign(template *x509.Certificate, key crypto.Signer, ca *KeyPair) (*KeyPair, error) {
+	parent, signer := template, key
+
+	if ca != nil {
+		parent, signer = ca.Cert, ca.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{Cert: cert, Key: key}, nil
+}
+
+// TLSCertificate returns the pair as a tls.Certificate
+func (p *KeyPair) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{p.Cert.Raw}, PrivateKey: p.Key, Leaf: p.Cert}
+}
+
+// Write writes the certificate and the key in PEM, the key is only readable by the owner
+func (p *KeyPair) Write(certFile string, keyFile string) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(p.Key)
+
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.Cert.Raw}), 0644)
+}
+
+// ReadKeyPair reads a pair written by Write
+func ReadKeyPair(certFile string, keyFile string) (*KeyPair, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := cert.PrivateKey.(crypto.Signer)
+
+	if !ok {
+		return nil, errors.New("the key of " + certFile + " can not sign")
+	}
+
+	return &KeyPair{Cert: leaf, Key: key}, nil
+}
+
+// LoadOrCreateCA reads the local CA of the directory, ca.crt and ca.key, it is created the first time
+func LoadOrCreateCA(dir string) (ca *KeyPair, created bool, err error) {
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+
+	if gos.IsExistFile(certFile) && gos.IsExistFile(keyFile) {
+		ca, err = ReadKeyPair(certFile, keyFile)
+
+		if err == nil && !ca.Cert.IsCA {
+			err = errors.New(certFile + " is not a CA certificate")
+		}
+
+		return ca, false, err
+	}
+
+	ca, err = GenerateCA(strings.TrimSpace(env.ProjectName+" Local CA"), CAValidity)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ca, true, ca.Write(certFile, keyFile)
+}
+
+// LoadOrCreateSelfSigned reads the self-signed certificate of the directory, server.crt and server.key.
+// It is created the first time, and created again when it expires within 30 days or misses one of the hosts.
+// The new pair is returned even when it can not be written.
+func LoadOrCreateSelfSigned(dir string, hosts []string) (*KeyPair, error) {
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+	if gos.IsExistFile(certFile) && gos.IsExistFile(keyFile) {
+		pair, err := ReadKeyPair(certFile, keyFile)
+
+		if err == nil && time.Now().Add(30*24*time.Hour).Before(pair.Cert.NotAfter) && coversHosts(pair.Cert, hosts) {
+			return pair, nil
+		}
+	}
+
+	pair, err := GenerateCertificate(hosts, ServerValidity, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, pair.Write(certFile, keyFile)
+}
+
+func coversHosts(cert *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DefaultHosts localhost, the loopback ips and the given hosts, without duplicates
+func DefaultHosts(hosts ...string) []string {
+	list := []string{"localhost", "127.0.0.1", "::1"}
+
+	for _, host := range hosts {
+		if host != "" && !slices.Contains(list, host) {
+			list = append(list, host)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,75 @@
+package https
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, created, err := LoadOrCreateCA(dir)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, ca.Cert.IsCA)
+
+	// the second time, the CA is read
+	again, created, err := LoadOrCreateCA(dir)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, ca.Cert.Raw, again.Cert.Raw)
+
+	pair, err := GenerateCertificate([]string{"www.example.com", "192.168.1.2", "::1"}, ServerValidity, ca)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"www.example.com"}, pair.Cert.DNSNames)
+	assert.Len(t, pair.Cert.IPAddresses, 2)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	for _, host := range []string{"www.example.com", "192.168.1.2", "::1"} {
+		_, err = pair.Cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		assert.NoError(t, err, host)
+	}
+
+	_, err = pair.Cert.Verify(x509.VerifyOptions{DNSName: "other.example.com", Roots: roots})
+	assert.Error(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "www.crt"), filepath.Join(dir, "www.key")
+	assert.NoError(t, pair.Write(certFile, keyFile))
+
+	info, err := os.Stat(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = LoadCertificate(certFile, keyFile)
+	assert.NoError(t, err)
+
+	_, err = GenerateCertificate(nil, ServerValidity, ca)
+	assert.Error(t, err)
+}
+
+func TestLoadOrCreateSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := LoadOrCreateSelfSigned(dir, DefaultHosts("10.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Cert.Issuer, first.Cert.Subject)
+	assert.True(t, first.Cert.NotAfter.After(time.Now().Add(365*24*time.Hour)))
+
+	// the same hosts, the certificate is kept
+	second, err := LoadOrCreateSelfSigned(dir, DefaultHosts("10.0.0.1", "127.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Cert.Raw, second.Cert.Raw)
+
+	// a new host, it is created again
+	third, err := LoadOrCreateSelfSigned(dir, DefaultHosts("10.0.0.2"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Cert.Raw, third.Cert.Raw)
+	assert.NoError(t, third.Cert.VerifyHostname("10.0.0.2"))
+}
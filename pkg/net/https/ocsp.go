@@ -0,0 +1,319 @@
+package https
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// OCSP statuses of a certificate
+const (
+	OCSPGood    = "good"
+	OCSPRevoked = "revoked"
+	OCSPUnknown = "unknown"
+)
+
+// ErrNoOCSPServer the certificate has no OCSP server, it can not be stapled
+var ErrNoOCSPServer = errors.New("the certificate has no ocsp server")
+
+// OCSPStatus the stapling state of a certificate
+type OCSPStatus struct {
+	// Status good, revoked or unknown, of the stapled or last response
+	Status     string
+	ThisUpdate time.Time
+	NextUpdate time.Time
+
+	// Stapled whether the response is stapled to the handshakes
+	Stapled bool
+
+	// Err the error of the last fetch, the previous response may still be stapled
+	Err error
+}
+
+// OCSPStatus returns the stapling state, nil before the first attempt or when the certificate has no OCSP server
+func (c *Certificate) OCSPStatus() *OCSPStatus {
+	return c.ocsp.Load()
+}
+
+// OCSPStapler fetches the OCSP responses of the certificates, staples them to the handshakes,
+// and refreshes them in the background.
+type OCSPStapler struct {
+	// CacheDir the responses are cached in its ocsp subdirectory, when it is set
+	CacheDir string
+
+	// Client the http client of the OCSP servers, with a timeout of 10 seconds by default
+	Client *http.Client
+}
+
+// Run staples the certificate until the context is done.
+// The response is refreshed halfway through its validity, and when the certificate is reloaded.
+func (s *OCSPStapler) Run(ctx context.Context, c *Certificate) {
+	logger := tools.Logger("https")
+
+	retry := time.Minute
+
+	for {
+		var wait <-chan time.Time
+
+		next, err := s.Staple(ctx, c)
+
+		switch {
+		case errors.Is(err, ErrNoOCSPServer):
+			// until it is reloaded
+		case err != nil:
+			logger.Warn("Failed to staple the OCSP response", "file", c.CertFile, "retry", retry, "error", err)
+
+			wait = time.After(retry)
+
+			if retry *= 2; retry > time.Hour {
+				retry = time.Hour
+			}
+		default:
+			retry = time.Minute
+			wait = time.After(time.Until(next))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.reloaded:
+			retry = time.Minute
+		case <-wait:
+		}
+	}
+}
+
+// Staple staples the cached or a fresh OCSP response of the certificate,
+// it returns when the response should be refreshed.
+func (s *OCSPStapler) Staple(ctx context.Context, c *Certificate) (time.Time, error) {
+	cert := c.cert.Load()
+
+	if cert == nil || cert.Leaf == nil || len(cert.Leaf.OCSPServer) == 0 {
+		return time.Time{}, ErrNoOCSPServer
+	}
+
+	issuer, err := s.issuer(ctx, cert)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	raw, resp := s.readCache(cert.Leaf, issuer)
+
+	var fetchErr error
+
+	if resp == nil || time.Now().After(refreshAt(resp)) {
+		var fetched *ocsp.Response
+		var fetchedRaw []byte
+
+		fetchedRaw, fetched, fetchErr = s.fetch(ctx, cert.Leaf, issuer)
+
+		if fetchErr == nil {
+			raw, resp = fetchedRaw, fetched
+			s.writeCache(cert.Leaf, raw)
+		}
+	}
+
+	if resp == nil {
+		// the previous response has expired
+		c.staple(cert, nil)
+		c.ocsp.Store(&OCSPStatus{Err: fetchErr})
+
+		return time.Time{}, fetchErr
+	}
+
+	status := &OCSPStatus{
+		Status:     ocspStatusName(resp.Status),
+		ThisUpdate: resp.ThisUpdate,
+		NextUpdate: resp.NextUpdate,
+		Err:        fetchErr,
+	}
+
+	// the other responses would make the clients reject the certificate
+	if resp.Status == ocsp.Good {
+		status.Stapled = c.staple(cert, raw)
+	} else {
+		c.staple(cert, nil)
+
+		tools.Logger("https").Error("The OCSP status of the certificate is not good", "file", c.CertFile, "status", status.Status)
+	}
+
+	c.ocsp.Store(status)
+
+	if fetchErr != nil {
+		return time.Time{}, fetchErr
+	}
+
+	return refreshAt(resp), nil
+}
+
+// staple replaces the staple of the certificate, unless it has been reloaded meanwhile
+func (c *Certificate) staple(cert *tls.Certificate, raw []byte) bool {
+	stapled := *cert
+	stapled.OCSPStaple = raw
+
+	return c.cert.CompareAndSwap(cert, &stapled)
+}
+
+// issuer the second certificate of the chain, or the one downloaded from the issuing certificate url
+func (s *OCSPStapler) issuer(ctx context.Context, cert *tls.Certificate) (*x509.Certificate, error) {
+	if len(cert.Certificate) > 1 {
+		return x509.ParseCertificate(cert.Certificate[1])
+	}
+
+	if len(cert.Leaf.IssuingCertificateURL) == 0 {
+		return nil, errors.New("the issuer is neither in the chain nor downloadable")
+	}
+
+	body, err := s.do(ctx, http.MethodGet, cert.Leaf.IssuingCertificateURL[0], nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(body); block != nil {
+		body = block.Bytes
+	}
+
+	issuer, err := x509.ParseCertificate(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return issuer, cert.Leaf.CheckSignatureFrom(issuer)
+}
+
+func (s *OCSPStapler) fetch(ctx context.Context, leaf *x509.Certificate, issuer *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(leaf, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := s.do(ctx, http.MethodPost, leaf.OCSPServer[0], req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return raw, resp, nil
+}
+
+func (s *OCSPStapler) do(ctx context.Context, method string, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+
+	client := s.Client
+
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (s *OCSPStapler) cacheFile(leaf *x509.Certificate) string {
+	sum := sha256.Sum256(leaf.Raw)
+
+	return filepath.Join(s.CacheDir, "ocsp", hex.EncodeToString(sum[:])+".der")
+}
+
+// readCache returns the cached response, unless it has expired
+func (s *OCSPStapler) readCache(leaf *x509.Certificate, issuer *x509.Certificate) ([]byte, *ocsp.Response) {
+	if s.CacheDir == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(s.cacheFile(leaf))
+
+	if err != nil {
+		return nil, nil
+	}
+
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+
+	if err != nil || (!resp.NextUpdate.IsZero() && time.Now().After(resp.NextUpdate)) {
+		return nil, nil
+	}
+
+	return raw, resp
+}
+
+func (s *OCSPStapler) writeCache(leaf *x509.Certificate, raw []byte) {
+	if s.CacheDir == "" {
+		return
+	}
+
+	file := s.cacheFile(leaf)
+
+	err := os.MkdirAll(filepath.Dir(file), 0700)
+
+	if err == nil {
+		err = os.WriteFile(file, raw, 0600)
+	}
+
+	if err != nil {
+		tools.Logger("https").Warn("Failed to cache the OCSP response", "file", file, "error", err)
+	}
+}
+
+// refreshAt halfway through the validity of the response, or in an hour when it has no next update
+func refreshAt(resp *ocsp.Response) time.Time {
+	if resp.NextUpdate.IsZero() {
+		return resp.ThisUpdate.Add(time.Hour)
+	}
+
+	return resp.ThisUpdate.Add(resp.NextUpdate.Sub(resp.ThisUpdate) / 2)
+}
+
+func ocspStatusName(status int) string {
+	switch status {
+	case ocsp.Good:
+		return OCSPGood
+	case ocsp.Revoked:
+		return OCSPRevoked
+	}
+
+	return OCSPUnknown
+}
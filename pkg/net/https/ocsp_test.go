@@ -0,0 +1,152 @@
+package https
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspResponder a local OCSP responder of the CA, which answers with the given status
+type ocspResponder struct {
+	server *httptest.Server
+	status atomic.Int64
+	hits   atomic.Int64
+}
+
+func newOCSPResponder(t *testing.T, ca *KeyPair) *ocspResponder {
+	r := &ocspResponder{}
+
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.hits.Add(1)
+
+		body, _ := io.ReadAll(req.Body)
+
+		request, err := ocsp.ParseRequest(body)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp, err := ocsp.CreateResponse(ca.Cert, ca.Cert, ocsp.Response{
+			Status:       int(r.status.Load()),
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, ca.Key)
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(resp)
+	}))
+
+	return r
+}
+
+// writeChain writes a certificate of the CA with the OCSP server, followed by the CA
+func writeChain(t *testing.T, dir string, ca *KeyPair, ocspServer string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		OCSPServer:   []string{ocspServer},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "chain.pem"), filepath.Join(dir, "chain.key")
+
+	chain := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw})...)
+
+	assert.NoError(t, os.WriteFile(certFile, chain, 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestOCSPStapler(t *testing.T) {
+	ctx := context.Background()
+
+	ca, err := GenerateCA("Test CA", time.Hour)
+	assert.NoError(t, err)
+
+	responder := newOCSPResponder(t, ca)
+	defer responder.server.Close()
+
+	certFile, keyFile := writeChain(t, t.TempDir(), ca, responder.server.URL)
+
+	certificate, err := LoadCertificate(certFile, keyFile)
+	assert.NoError(t, err)
+
+	stapler := &OCSPStapler{CacheDir: t.TempDir()}
+
+	next, err := stapler.Staple(ctx, certificate)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), next, time.Minute)
+	assert.NotEmpty(t, certificate.Certificate().OCSPStaple)
+	assert.Equal(t, OCSPGood, certificate.OCSPStatus().Status)
+	assert.True(t, certificate.OCSPStatus().Stapled)
+	assert.Equal(t, int64(1), responder.hits.Load())
+
+	// after a reload, the cached response is stapled without asking the responder
+	assert.NoError(t, certificate.Reload())
+	assert.Empty(t, certificate.Certificate().OCSPStaple)
+
+	_, err = stapler.Staple(ctx, certificate)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, certificate.Certificate().OCSPStaple)
+	assert.Equal(t, int64(1), responder.hits.Load())
+
+	// a revoked certificate is not stapled
+	responder.status.Store(ocsp.Revoked)
+
+	_, err = (&OCSPStapler{}).Staple(ctx, certificate)
+	assert.NoError(t, err)
+	assert.Empty(t, certificate.Certificate().OCSPStaple)
+	assert.Equal(t, OCSPRevoked, certificate.OCSPStatus().Status)
+	assert.False(t, certificate.OCSPStatus().Stapled)
+
+	// the responder is down
+	responder.server.Close()
+
+	_, err = (&OCSPStapler{}).Staple(ctx, certificate)
+	assert.Error(t, err)
+	assert.Error(t, certificate.OCSPStatus().Err)
+
+	// without OCSP server
+	dir := t.TempDir()
+	writeCertificate(t, filepath.Join(dir, "a.pem"), filepath.Join(dir, "a.key"), "a.example.com")
+
+	selfSigned, err := LoadCertificate(filepath.Join(dir, "a.pem"), filepath.Join(dir, "a.key"))
+	assert.NoError(t, err)
+
+	_, err = stapler.Staple(ctx, selfSigned)
+	assert.ErrorIs(t, err, ErrNoOCSPServer)
+	assert.Nil(t, selfSigned.OCSPStatus())
+}
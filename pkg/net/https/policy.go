@@ -0,0 +1,214 @@
+package https
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// TLS policy presets, after the Mozilla server side TLS guidelines
+const (
+	// PolicyModern TLS 1.3 only
+	PolicyModern = "modern"
+
+	// PolicyIntermediate TLS 1.2 and 1.3, with the AEAD cipher suites and forward secrecy
+	PolicyIntermediate = "intermediate"
+
+	// PolicyLegacy TLS 1.0 to 1.3, for the very old clients
+	PolicyLegacy = "legacy"
+)
+
+// Policy the versions, the cipher suites and the curves of the TLS connections
+type Policy struct {
+	Name             string
+	MinVersion       uint16
+	MaxVersion       uint16
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+}
+
+var defaultCurves = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var legacyCipherSuites = append(slices.Clone(intermediateCipherSuites),
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+)
+
+// PolicyPreset returns the policy of a preset: modern, intermediate or legacy
+func PolicyPreset(name string) (Policy, error) {
+	switch strings.ToLower(name) {
+	case PolicyModern:
+		return Policy{
+			Name:             PolicyModern,
+			MinVersion:       tls.VersionTLS13,
+			MaxVersion:       tls.VersionTLS13,
+			CurvePreferences: slices.Clone(defaultCurves),
+		}, nil
+	case "", PolicyIntermediate:
+		return Policy{
+			Name:             PolicyIntermediate,
+			MinVersion:       tls.VersionTLS12,
+			MaxVersion:       tls.VersionTLS13,
+			CipherSuites:     slices.Clone(intermediateCipherSuites),
+			CurvePreferences: slices.Clone(defaultCurves),
+		}, nil
+	case PolicyLegacy, "old":
+		return Policy{
+			Name:             PolicyLegacy,
+			MinVersion:       tls.VersionTLS10,
+			MaxVersion:       tls.VersionTLS13,
+			CipherSuites:     slices.Clone(legacyCipherSuites),
+			CurvePreferences: slices.Clone(defaultCurves),
+		}, nil
+	}
+
+	return Policy{}, fmt.Errorf("unknown tls policy %q, it should be modern, intermediate or legacy", name)
+}
+
+// Validate checks that the versions are in order,
+// and that every cipher suite can be used by one of the versions
+func (p Policy) Validate() error {
+	if p.MinVersion > p.MaxVersion {
+		return fmt.Errorf("the min tls version %s is above the max version %s", tls.VersionName(p.MinVersion), tls.VersionName(p.MaxVersion))
+	}
+
+	for _, id := range p.CipherSuites {
+		suite := cipherSuite(id)
+
+		if suite == nil {
+			return fmt.Errorf("unknown cipher suite 0x%04X", id)
+		}
+
+		if !slices.ContainsFunc(suite.SupportedVersions, func(v uint16) bool { return v >= p.MinVersion && v <= p.MaxVersion }) {
+			return fmt.Errorf("the cipher suite %s can not be used from %s to %s", suite.Name, tls.VersionName(p.MinVersion), tls.VersionName(p.MaxVersion))
+		}
+	}
+
+	return nil
+}
+
+// Apply sets the policy on the tls config
+func (p Policy) Apply(conf *tls.Config) {
+	conf.MinVersion = p.MinVersion
+	conf.MaxVersion = p.MaxVersion
+	conf.CipherSuites = p.CipherSuites
+	conf.CurvePreferences = p.CurvePreferences
+}
+
+// LogValue logs the names of the versions, the cipher suites and the curves
+func (p Policy) LogValue() slog.Value {
+	suites := make([]string, 0, len(p.CipherSuites))
+
+	for _, id := range p.CipherSuites {
+		suites = append(suites, tls.CipherSuiteName(id))
+	}
+
+	curves := make([]string, 0, len(p.CurvePreferences))
+
+	for _, id := range p.CurvePreferences {
+		curves = append(curves, id.String())
+	}
+
+	return slog.GroupValue(
+		slog.String("name", p.Name),
+		slog.String("min_version", tls.VersionName(p.MinVersion)),
+		slog.String("max_version", tls.VersionName(p.MaxVersion)),
+		slog.Any("cipher_suites", suites),
+		slog.Any("curves", curves),
+	)
+}
+
+// ParseTLSVersion parses 1.0, 1.1, 1.2 or 1.3, with an optional TLS prefix
+func ParseTLSVersion(s string) (uint16, error) {
+	version := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "tls"), "v"))
+
+	switch version {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("unknown tls version %q, it should be 1.0, 1.1, 1.2 or 1.3", s)
+}
+
+// ParseCipherSuites parses the IANA names of cipher suites, eg: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	ids := make([]uint16, 0, len(names))
+
+	for _, name := range names {
+		var found *tls.CipherSuite
+
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			if strings.EqualFold(suite.Name, strings.TrimSpace(name)) {
+				found = suite
+				break
+			}
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+
+		ids = append(ids, found.ID)
+	}
+
+	return ids, nil
+}
+
+// ParseCurves parses the names of curves: X25519, P-256, P-384 or P-521
+func ParseCurves(names []string) ([]tls.CurveID, error) {
+	curves := make([]tls.CurveID, 0, len(names))
+
+	for _, name := range names {
+		switch strings.ToUpper(strings.TrimSpace(name)) {
+		case "X25519":
+			curves = append(curves, tls.X25519)
+		case "P-256", "P256", "CURVEP256":
+			curves = append(curves, tls.CurveP256)
+		case "P-384", "P384", "CURVEP384":
+			curves = append(curves, tls.CurveP384)
+		case "P-521", "P521", "CURVEP521":
+			curves = append(curves, tls.CurveP521)
+		default:
+			return nil, fmt.Errorf("unknown curve %q, it should be X25519, P-256, P-384 or P-521", name)
+		}
+	}
+
+	return curves, nil
+}
+
+func cipherSuite(id uint16) *tls.CipherSuite {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if suite.ID == id {
+			return suite
+		}
+	}
+
+	return nil
+}
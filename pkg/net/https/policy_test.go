@@ -0,0 +1,75 @@
+package https
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyPreset(t *testing.T) {
+	for _, name := range []string{"", PolicyModern, PolicyIntermediate, PolicyLegacy, "Old"} {
+		policy, err := PolicyPreset(name)
+
+		assert.NoError(t, err, name)
+		assert.NoError(t, policy.Validate(), name)
+	}
+
+	modern, _ := PolicyPreset(PolicyModern)
+	assert.Equal(t, uint16(tls.VersionTLS13), modern.MinVersion)
+	assert.Empty(t, modern.CipherSuites)
+
+	legacy, _ := PolicyPreset(PolicyLegacy)
+	assert.Equal(t, uint16(tls.VersionTLS10), legacy.MinVersion)
+	assert.Contains(t, legacy.CipherSuites, tls.TLS_RSA_WITH_AES_128_CBC_SHA)
+
+	_, err := PolicyPreset("paranoid")
+	assert.Error(t, err)
+}
+
+func TestPolicyValidate(t *testing.T) {
+	policy, _ := PolicyPreset(PolicyIntermediate)
+
+	policy.MinVersion = tls.VersionTLS13
+	policy.MaxVersion = tls.VersionTLS12
+	assert.Error(t, policy.Validate())
+
+	// a TLS 1.2 cipher suite with TLS 1.3 only
+	policy.MaxVersion = tls.VersionTLS13
+	policy.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	assert.Error(t, policy.Validate())
+
+	policy.MinVersion = tls.VersionTLS12
+	assert.NoError(t, policy.Validate())
+
+	// a TLS 1.3 cipher suite with TLS 1.2 only
+	policy.MaxVersion = tls.VersionTLS12
+	policy.CipherSuites = []uint16{tls.TLS_AES_128_GCM_SHA256}
+	assert.Error(t, policy.Validate())
+}
+
+func TestParsePolicy(t *testing.T) {
+	for s, version := range map[string]uint16{"1.0": tls.VersionTLS10, "TLS1.2": tls.VersionTLS12, "tlsv1.3": tls.VersionTLS13, "13": tls.VersionTLS13} {
+		parsed, err := ParseTLSVersion(s)
+
+		assert.NoError(t, err, s)
+		assert.Equal(t, version, parsed, s)
+	}
+
+	_, err := ParseTLSVersion("3.0")
+	assert.Error(t, err)
+
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "tls_rsa_with_3des_ede_cbc_sha"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA}, suites)
+
+	_, err = ParseCipherSuites([]string{"ECDHE-RSA-AES128-GCM-SHA256"})
+	assert.Error(t, err)
+
+	curves, err := ParseCurves([]string{"x25519", "P-256", "P384"})
+	assert.NoError(t, err)
+	assert.Equal(t, []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}, curves)
+
+	_, err = ParseCurves([]string{"P-224"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package https
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RedirectHandler redirects the requests to the same host, path and query over https,
+// on port, which is omitted when it is 443.
+func RedirectHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			// an ipv6 address
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+
+		code := http.StatusMovedPermanently
+
+		// the other methods must be repeated with their body
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+
+		http.Redirect(w, r, target, code)
+	})
+}
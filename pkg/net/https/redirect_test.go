@@ -0,0 +1,38 @@
+package https
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		port     string
+		method   string
+		target   string
+		host     string
+		code     int
+		location string
+	}{
+		{"443", http.MethodGet, "/a/b.txt?x=1&y=2", "example.com", http.StatusMovedPermanently, "https://example.com/a/b.txt?x=1&y=2"},
+		{"8443", http.MethodGet, "/", "example.com:8080", http.StatusMovedPermanently, "https://example.com:8443/"},
+		{"", http.MethodHead, "/a%20b", "example.com:80", http.StatusMovedPermanently, "https://example.com/a%20b"},
+		{"443", http.MethodPost, "/upload", "[::1]:80", http.StatusPermanentRedirect, "https://[::1]/upload"},
+		{"8443", http.MethodPut, "/upload", "[::1]", http.StatusPermanentRedirect, "https://[::1]:8443/upload"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.target, nil)
+		r.Host = test.host
+
+		w := httptest.NewRecorder()
+
+		RedirectHandler(test.port).ServeHTTP(w, r)
+
+		assert.Equal(t, test.code, w.Code, test.target)
+		assert.Equal(t, test.location, w.Header().Get("Location"), test.target)
+	}
+}
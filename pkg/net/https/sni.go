@@ -0,0 +1,181 @@
+package https
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	gos "snowdream.tech/http-server/pkg/os"
+)
+
+// Certificates selects the certificate of a connection by its server name (SNI),
+// among the DNS names of the certificates, wildcards included.
+// The names which match none of them are served by ACME when it allows them,
+// otherwise by the default certificate, the first one.
+//
+// The certificates are added before the server starts, they can be reloaded later.
+type Certificates struct {
+	// ACME the manager of the other names, optional
+	ACME *autocert.Manager
+
+	list []*Certificate
+}
+
+// Add adds a certificate, the first one is the default certificate
+func (s *Certificates) Add(certs ...*Certificate) {
+	s.list = append(s.list, certs...)
+}
+
+// List returns the certificates
+func (s *Certificates) List() []*Certificate {
+	return s.list
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate
+func (s *Certificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	// the tls-alpn-01 challenges
+	if s.ACME != nil && slices.Contains(hello.SupportedProtos, acme.ALPNProto) {
+		return s.ACME.GetCertificate(hello)
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+
+	if cert := s.match(hello, name); cert != nil {
+		return cert, nil
+	}
+
+	if s.ACME != nil && name != "" && (s.ACME.HostPolicy == nil || s.ACME.HostPolicy(hello.Context(), name) == nil) {
+		return s.ACME.GetCertificate(hello)
+	}
+
+	if len(s.list) > 0 {
+		return s.list[0].Certificate(), nil
+	}
+
+	if s.ACME != nil {
+		return s.ACME.GetCertificate(hello)
+	}
+
+	return nil, errors.New("no certificate is loaded")
+}
+
+// match returns the certificate of the name, an exact name is preferred to a wildcard.
+// Among several certificates of a name, eg: RSA and ECDSA, the first one supported by the client is chosen.
+func (s *Certificates) match(hello *tls.ClientHelloInfo, name string) *tls.Certificate {
+	if name == "" {
+		return nil
+	}
+
+	wildcard := ""
+
+	if _, parent, ok := strings.Cut(name, "."); ok {
+		wildcard = "*." + parent
+	}
+
+	for _, pattern := range []string{name, wildcard} {
+		if pattern == "" {
+			continue
+		}
+
+		var found *tls.Certificate
+
+		for _, c := range s.list {
+			cert := c.Certificate()
+
+			if cert == nil || cert.Leaf == nil || !hasName(cert, pattern) {
+				continue
+			}
+
+			if hello.SupportsCertificate(cert) == nil {
+				return cert
+			}
+
+			if found == nil {
+				found = cert
+			}
+		}
+
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// hasName reports whether the certificate is issued for the name, or the common name without DNS names
+func hasName(cert *tls.Certificate, name string) bool {
+	names := cert.Leaf.DNSNames
+
+	if len(names) == 0 && cert.Leaf.Subject.CommonName != "" {
+		names = []string{cert.Leaf.Subject.CommonName}
+	}
+
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSuffix(n, "."), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadCertificatesDir loads the <name>.crt (or .pem, .cer) and <name>.key pairs of the directory,
+// and the <name>/fullchain.pem and <name>/privkey.pem pairs, like certbot.
+func LoadCertificatesDir(dir string) ([]*Certificate, error) {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*Certificate
+
+	for _, entry := range entries {
+		var certFile, keyFile string
+
+		if entry.IsDir() {
+			certFile = filepath.Join(dir, entry.Name(), "fullchain.pem")
+			keyFile = filepath.Join(dir, entry.Name(), "privkey.pem")
+
+			if !gos.IsExistFile(certFile) || !gos.IsExistFile(keyFile) {
+				continue
+			}
+		} else if filepath.Ext(entry.Name()) == ".key" {
+			keyFile = filepath.Join(dir, entry.Name())
+			base := strings.TrimSuffix(keyFile, ".key")
+
+			for _, ext := range []string{".crt", ".pem", ".cer"} {
+				if gos.IsExistFile(base + ext) {
+					certFile = base + ext
+					break
+				}
+			}
+
+			if certFile == "" {
+				continue
+			}
+		} else {
+			continue
+		}
+
+		cert, err := LoadCertificate(certFile, keyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found in " + dir)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,57 @@
+package https
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificatesSNI(t *testing.T) {
+	dir := t.TempDir()
+
+	writeCertificate(t, filepath.Join(dir, "default.crt"), filepath.Join(dir, "default.key"), "default.test")
+	writeCertificate(t, filepath.Join(dir, "exact.pem"), filepath.Join(dir, "exact.key"), "www.example.com")
+	writeCertificate(t, filepath.Join(dir, "wildcard.crt"), filepath.Join(dir, "wildcard.key"), "*.example.com")
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "other.org"), 0700))
+	writeCertificate(t, filepath.Join(dir, "other.org", "fullchain.pem"), filepath.Join(dir, "other.org", "privkey.pem"), "other.org")
+
+	// a key without certificate is skipped
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "orphan.key"), []byte("key"), 0600))
+
+	list, err := LoadCertificatesDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, list, 4)
+
+	var certificates Certificates
+	certificates.Add(list...)
+
+	tests := []struct {
+		serverName string
+		name       string
+	}{
+		{"www.example.com", "www.example.com"},
+		{"WWW.Example.com.", "www.example.com"},
+		{"api.example.com", "*.example.com"},
+		{"a.b.example.com", "default.test"},
+		{"example.com", "default.test"},
+		{"other.org", "other.org"},
+		{"", "default.test"},
+	}
+
+	for _, test := range tests {
+		cert, err := certificates.GetCertificate(&tls.ClientHelloInfo{ServerName: test.serverName})
+
+		assert.NoError(t, err, test.serverName)
+		assert.Equal(t, test.name, cert.Leaf.DNSNames[0], test.serverName)
+	}
+
+	_, err = LoadCertificatesDir(t.TempDir())
+	assert.Error(t, err)
+
+	_, err = (&Certificates{}).GetCertificate(&tls.ClientHelloInfo{ServerName: "www.example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package https
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"time"
+
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// TicketKeys the number of session ticket keys which are kept,
+// the sessions resumed with the previous keys are issued a ticket with the current one.
+const TicketKeys = 3
+
+// RotateSessionTicketKeys replaces the session ticket key of the config every interval,
+// until stop is called
+func RotateSessionTicketKeys(conf *tls.Config, interval time.Duration) (stop func(), err error) {
+	keys := make([][32]byte, 0, TicketKeys)
+
+	rotate := func() error {
+		var key [32]byte
+
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+
+		keys = append([][32]byte{key}, keys...)
+
+		if len(keys) > TicketKeys {
+			keys = keys[:TicketKeys]
+		}
+
+		conf.SetSessionTicketKeys(keys)
+
+		return nil
+	}
+
+	if err := rotate(); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := rotate(); err != nil {
+					tools.Logger("https").Error("Failed to rotate the session ticket keys", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}, nil
+}
@@ -0,0 +1,356 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefixes of the listen addresses which are not host:port
+const (
+	// UnixPrefix unix:/path/to/socket
+	UnixPrefix = "unix:"
+
+	// FDPrefix fd:N, an inherited file descriptor
+	FDPrefix = "fd:"
+
+	// Systemd systemd, systemd:name or systemd:N, the sockets passed by systemd socket activation
+	Systemd = "systemd"
+)
+
+// SocketOptions the permissions of the unix sockets
+type SocketOptions struct {
+	// Mode the file mode, eg: 0660, the umask applies when it is 0
+	Mode os.FileMode
+
+	// Owner user, user:group or :group, by name or id
+	Owner string
+}
+
+// ParseSocketOptions parses an octal mode, eg: 0660, and an owner
+func ParseSocketOptions(mode string, owner string) (SocketOptions, error) {
+	opts := SocketOptions{Owner: owner}
+
+	if mode != "" {
+		m, err := strconv.ParseUint(mode, 8, 32)
+
+		if err != nil || m > 0777 {
+			return opts, fmt.Errorf("invalid socket mode %q, it should be octal, eg: 0660", mode)
+		}
+
+		opts.Mode = os.FileMode(m)
+	}
+
+	return opts, nil
+}
+
+// IsTCPAddress reports whether the address is host:port, not a unix socket or an inherited one
+func IsTCPAddress(address string) bool {
+	return !strings.HasPrefix(address, UnixPrefix) && !strings.HasPrefix(address, FDPrefix) &&
+		address != Systemd && !strings.HasPrefix(address, Systemd+":")
+}
+
+// Listen listens on an address:
+//   - host:port, [ipv6]:port or :port, an IPv4 or IPv6 host binds that family only
+//   - unix:/path/to/socket
+//   - fd:N, an inherited file descriptor
+//   - systemd, systemd:name or systemd:N, the sockets passed by systemd socket activation,
+//     all of them, those of the FileDescriptorName, or the Nth one
+func Listen(address string, opts SocketOptions) ([]net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, UnixPrefix):
+		l, err := listenUnix(strings.TrimPrefix(address, UnixPrefix), opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	case strings.HasPrefix(address, FDPrefix):
+		fd, err := strconv.Atoi(strings.TrimPrefix(address, FDPrefix))
+
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor %q", address)
+		}
+
+		f := os.NewFile(uintptr(fd), address)
+		defer f.Close()
+
+		l, err := fileListener(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	case !IsTCPAddress(address):
+		return listenSystemd(strings.TrimPrefix(strings.TrimPrefix(address, Systemd), ":"))
+	}
+
+	l, err := net.Listen(network("tcp", address), address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []net.Listener{l}, nil
+}
+
+// ListenPacket listens on the udp port of a host:port address, eg: for HTTP/3
+func ListenPacket(address string) (net.PacketConn, error) {
+	return net.ListenPacket(network("udp", address), address)
+}
+
+// network tcp4 or tcp6 when the host is an IPv4 or an IPv6, both otherwise
+func network(proto string, address string) string {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return proto
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			return proto + "4"
+		}
+
+		return proto + "6"
+	}
+
+	return proto
+}
+
+func listenUnix(path string, opts SocketOptions) (net.Listener, error) {
+	// the socket of a previous run, unless it is still served
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("the socket %s is in use", path)
+		}
+
+		os.Remove(path)
+	}
+
+	l, err := net.Listen("unix", path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mode != 0 {
+		err = os.Chmod(path, opts.Mode)
+	}
+
+	if err == nil && opts.Owner != "" {
+		var uid, gid int
+
+		if uid, gid, err = lookupOwner(opts.Owner); err == nil {
+			err = os.Chown(path, uid, gid)
+		}
+	}
+
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return withUnixPeer(l), nil
+}
+
+// lookupOwner returns the ids of user:group, -1 when one of them is empty
+func lookupOwner(owner string) (uid int, gid int, err error) {
+	name, group, _ := strings.Cut(owner, ":")
+
+	uid, gid = -1, -1
+
+	if name != "" {
+		if uid, err = strconv.Atoi(name); err != nil {
+			u, err := user.Lookup(name)
+
+			if err != nil {
+				return 0, 0, err
+			}
+
+			uid, _ = strconv.Atoi(u.Uid)
+
+			// the primary group of the user, unless the group is given
+			if group == "" && strings.Contains(owner, ":") {
+				gid, _ = strconv.Atoi(u.Gid)
+			}
+		}
+	}
+
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+
+			if err != nil {
+				return 0, 0, err
+			}
+
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+
+	return uid, gid, nil
+}
+
+// fileListener the listener has its own copy of the file descriptor
+func fileListener(f *os.File) (net.Listener, error) {
+	l, err := net.FileListener(f)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a listening socket: %w", f.Name(), err)
+	}
+
+	return withUnixPeer(l), nil
+}
+
+// UnixPeer the remote address of the connections of the unix sockets, which have none.
+// It is not a loopback address, so that it is neither allow-listed nor trusted as a proxy
+// by default, see UnixProxy.
+var UnixPeer net.Addr = &net.TCPAddr{IP: net.IPv4zero}
+
+// UnixProxy the trusted proxy which stands for the peers of the unix sockets, see TrustedProxies
+const UnixProxy = "unix"
+
+// TrustedProxies the ips or CIDRs of the trusted proxies, UnixProxy is replaced by UnixPeer
+func TrustedProxies(entries []string) []string {
+	proxies := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry == UnixProxy {
+			entry = UnixPeer.(*net.TCPAddr).IP.String()
+		}
+
+		proxies = append(proxies, entry)
+	}
+
+	return proxies
+}
+
+// withUnixPeer the connections of a unix listener report UnixPeer as their remote address
+func withUnixPeer(l net.Listener) net.Listener {
+	if l.Addr().Network() != "unix" {
+		return l
+	}
+
+	return unixListener{l}
+}
+
+type unixListener struct {
+	net.Listener
+}
+
+func (l unixListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unixConn{conn}, nil
+}
+
+type unixConn struct {
+	net.Conn
+}
+
+func (c unixConn) RemoteAddr() net.Addr {
+	return UnixPeer
+}
+
+// listenFDsStart the first file descriptor passed by systemd
+const listenFDsStart = 3
+
+var (
+	systemdOnce  sync.Once
+	systemdFiles []*os.File
+)
+
+// listenSystemd the sockets of systemd, all of them, those of the name, or the Nth one
+func listenSystemd(selector string) ([]net.Listener, error) {
+	systemdOnce.Do(func() {
+		names := parseListenFDs(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES"), os.Getpid())
+
+		for i, name := range names {
+			systemdFiles = append(systemdFiles, os.NewFile(uintptr(listenFDsStart+i), name))
+		}
+
+		// they are not passed to the child processes
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	})
+
+	if len(systemdFiles) == 0 {
+		return nil, errors.New("no socket is passed by systemd, LISTEN_FDS is not set")
+	}
+
+	index, err := strconv.Atoi(selector)
+	numeric := err == nil
+
+	if numeric && (index < 0 || index >= len(systemdFiles)) {
+		return nil, fmt.Errorf("systemd passed %d sockets, there is no socket %d", len(systemdFiles), index)
+	}
+
+	var listeners []net.Listener
+
+	for i, f := range systemdFiles {
+		if selector != "" && f.Name() != selector && (!numeric || i != index) {
+			continue
+		}
+
+		l, err := fileListener(f)
+
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+
+			return nil, err
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("systemd passed no socket named %q", selector)
+	}
+
+	return listeners, nil
+}
+
+// parseListenFDs returns the names of the sockets passed by systemd to the process,
+// LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES, see sd_listen_fds(3)
+func parseListenFDs(listenPID string, listenFDs string, listenFDNames string, pid int) []string {
+	if p, err := strconv.Atoi(listenPID); err != nil || p != pid {
+		return nil
+	}
+
+	n, err := strconv.Atoi(listenFDs)
+
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	names := make([]string, n)
+	given := strings.Split(listenFDNames, ":")
+
+	for i := range names {
+		// the default name of systemd
+		names[i] = "unknown"
+
+		if i < len(given) && given[i] != "" {
+			names[i] = given[i]
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,168 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetwork(t *testing.T) {
+	assert.Equal(t, "tcp4", network("tcp", "0.0.0.0:8080"))
+	assert.Equal(t, "tcp6", network("tcp", "[::]:8080"))
+	assert.Equal(t, "udp6", network("udp", "[::1]:8443"))
+	assert.Equal(t, "tcp", network("tcp", ":8080"))
+	assert.Equal(t, "tcp", network("tcp", "localhost:8080"))
+
+	assert.True(t, IsTCPAddress("127.0.0.1:8080"))
+	assert.False(t, IsTCPAddress("unix:/run/http-server.sock"))
+	assert.False(t, IsTCPAddress("fd:3"))
+	assert.False(t, IsTCPAddress("systemd"))
+	assert.False(t, IsTCPAddress("systemd:https"))
+}
+
+func TestParseSocketOptions(t *testing.T) {
+	opts, err := ParseSocketOptions("0660", "www-data:")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), opts.Mode)
+	assert.Equal(t, "www-data:", opts.Owner)
+
+	_, err = ParseSocketOptions("rw-rw----", "")
+	assert.Error(t, err)
+
+	uid, gid, err := lookupOwner("1000:1001")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, uid)
+	assert.Equal(t, 1001, gid)
+
+	uid, gid, err = lookupOwner(":0")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, uid)
+	assert.Equal(t, 0, gid)
+}
+
+func TestListen(t *testing.T) {
+	listeners, err := Listen("127.0.0.1:0", SocketOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, listeners, 1)
+	assert.True(t, listeners[0].Addr().(*net.TCPAddr).IP.Equal(net.IPv4(127, 0, 0, 1)))
+
+	// an inherited file descriptor
+	f, err := listeners[0].(*net.TCPListener).File()
+	assert.NoError(t, err)
+
+	inherited, err := Listen(fmt.Sprintf("fd:%d", f.Fd()), SocketOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, listeners[0].Addr().String(), inherited[0].Addr().String())
+
+	inherited[0].Close()
+	listeners[0].Close()
+
+	_, err = Listen("fd:x", SocketOptions{})
+	assert.Error(t, err)
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+
+	// the stale socket of a previous run
+	stale, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := Listen(UnixPrefix+path, SocketOptions{Mode: 0600})
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the socket is in use
+	_, err = Listen(UnixPrefix+path, SocketOptions{})
+	assert.Error(t, err)
+
+	listeners[0].Close()
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestListenUnixClientIP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+
+	listeners, err := Listen(UnixPrefix+path, SocketOptions{})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	assert.NoError(t, engine.SetTrustedProxies(nil))
+
+	server := &http.Server{Handler: engine}
+
+	go server.Serve(listeners[0])
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	get := func(forwardedFor string) string {
+		req, _ := http.NewRequest(http.MethodGet, "http://unix/", nil)
+
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		resp, err := client.Do(req)
+
+		if !assert.NoError(t, err) {
+			return ""
+		}
+
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body)
+	}
+
+	// the peer is not a loopback address, nor a trusted proxy
+	assert.Equal(t, "0.0.0.0", get("127.0.0.1"))
+	assert.Equal(t, "0.0.0.0", get(""))
+
+	// unless the reverse proxy is trusted
+	assert.NoError(t, engine.SetTrustedProxies(TrustedProxies([]string{UnixProxy})))
+
+	assert.Equal(t, "203.0.113.7", get("203.0.113.7"))
+	assert.Equal(t, "0.0.0.0", get(""))
+}
+
+func TestTrustedProxies(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.0/8", "0.0.0.0"}, TrustedProxies([]string{"10.0.0.0/8", UnixProxy}))
+	assert.Empty(t, TrustedProxies(nil))
+}
+
+func TestParseListenFDs(t *testing.T) {
+	assert.Equal(t, []string{"http", "https"}, parseListenFDs("42", "2", "http:https", 42))
+	assert.Equal(t, []string{"http", "unknown"}, parseListenFDs("42", "2", "http", 42))
+	assert.Nil(t, parseListenFDs("41", "2", "http:https", 42))
+	assert.Nil(t, parseListenFDs("42", "", "", 42))
+
+	_, err := listenSystemd("")
+	assert.Error(t, err)
+}
@@ -44,7 +44,7 @@ func GetAvailableIPS() []string {
 	// get list of available addresses
 	addr, err := net.InterfaceAddrs()
 	if err != nil {
-		tools.DebugPrintF("[ERROR] " + err.Error())
+		tools.Logger("net").Error("Failed to list the interface addresses", "error", err)
 		return ips
 	}
 
@@ -0,0 +1,141 @@
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTTL is returned when a link would never expire
+var ErrTTL = errors.New("the ttl of a share link must be positive")
+
+// Link shares one file without authentication, until it expires or is revoked
+type Link struct {
+	Token     string    `json:"token" xml:"token" yaml:"token"`
+	Path      string    `json:"path" xml:"path" yaml:"path"`
+	CreatedBy string    `json:"createdby,omitempty" xml:"createdby,omitempty" yaml:"createdby,omitempty"`
+	Created   time.Time `json:"created" xml:"created" yaml:"created"`
+	Expires   time.Time `json:"expires" xml:"expires" yaml:"expires"`
+	Downloads int64     `json:"downloads" xml:"downloads" yaml:"downloads"`
+}
+
+// Links the share links of the server, they are kept in memory
+type Links struct {
+	mu    sync.Mutex
+	links map[string]*Link
+}
+
+var defaultLinks = NewLinks()
+
+// NewLinks NewLinks
+func NewLinks() *Links {
+	return &Links{links: map[string]*Link{}}
+}
+
+// Default the share links of the server
+func Default() *Links {
+	return defaultLinks
+}
+
+// Mint creates a link to path, valid for ttl
+func (l *Links) Mint(path string, ttl time.Duration, createdBy string) (Link, error) {
+	if ttl <= 0 {
+		return Link{}, ErrTTL
+	}
+
+	token, err := randomToken()
+
+	if err != nil {
+		return Link{}, err
+	}
+
+	now := time.Now()
+
+	link := &Link{
+		Token:     token,
+		Path:      path,
+		CreatedBy: createdBy,
+		Created:   now,
+		Expires:   now.Add(ttl),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.links[token] = link
+
+	return *link, nil
+}
+
+// Use returns the link of the token, and counts a download.
+// Expired links are removed.
+func (l *Links) Use(token string) (Link, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	link, ok := l.links[token]
+
+	if !ok {
+		return Link{}, false
+	}
+
+	if time.Now().After(link.Expires) {
+		delete(l.links, token)
+		return Link{}, false
+	}
+
+	link.Downloads++
+
+	return *link, true
+}
+
+// Revoke removes the link of the token, it reports whether the link existed
+func (l *Links) Revoke(token string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.links[token]
+
+	delete(l.links, token)
+
+	return ok
+}
+
+// List returns the links which have not expired, the oldest first
+func (l *Links) List() []Link {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	list := make([]Link, 0, len(l.links))
+
+	for token, link := range l.links {
+		if now.After(link.Expires) {
+			delete(l.links, token)
+			continue
+		}
+
+		list = append(list, *link)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+
+	return list
+}
+
+// randomToken 32 random bytes, url-safe
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
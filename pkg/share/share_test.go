@@ -0,0 +1,55 @@
+package share
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks(t *testing.T) {
+	links := NewLinks()
+
+	_, err := links.Mint("/a.txt", 0, "")
+	assert.ErrorIs(t, err, ErrTTL)
+
+	link, err := links.Mint("/a.txt", time.Hour, "alice")
+	assert.NoError(t, err)
+	assert.Len(t, link.Token, 43)
+	assert.Equal(t, "alice", link.CreatedBy)
+
+	other, err := links.Mint("/b.txt", time.Hour, "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, link.Token, other.Token)
+
+	used, ok := links.Use(link.Token)
+	assert.True(t, ok)
+	assert.Equal(t, "/a.txt", used.Path)
+	assert.Equal(t, int64(1), used.Downloads)
+
+	_, ok = links.Use("unknown")
+	assert.False(t, ok)
+
+	list := links.List()
+	assert.Len(t, list, 2)
+	assert.Equal(t, link.Token, list[0].Token)
+
+	assert.True(t, links.Revoke(link.Token))
+	assert.False(t, links.Revoke(link.Token))
+
+	_, ok = links.Use(link.Token)
+	assert.False(t, ok)
+}
+
+func TestExpiredLinks(t *testing.T) {
+	links := NewLinks()
+
+	link, err := links.Mint("/a.txt", time.Nanosecond, "")
+	assert.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+
+	_, ok := links.Use(link.Token)
+	assert.False(t, ok)
+	assert.Empty(t, links.List())
+}
@@ -0,0 +1,353 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"snowdream.tech/http-server/pkg/tools"
+)
+
+// Limits of the tracked entries
+const (
+	// MaxRecentErrors the number of recent errors which are kept
+	MaxRecentErrors = 100
+
+	// MaxTopEntries the number of files or clients which are counted, the counters are reset beyond
+	MaxTopEntries = 10000
+)
+
+// ErrKicked is returned by the writes of a kicked download
+var ErrKicked = errors.New("the download has been kicked")
+
+// Download an active download
+type Download struct {
+	// ID is generated by the tracker, the request id is chosen by the client
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	RequestID string    `json:"requestid,omitempty" xml:"requestid,omitempty" yaml:"requestid,omitempty"`
+	Path      string    `json:"path" xml:"path" yaml:"path"`
+	Client    string    `json:"client" xml:"client" yaml:"client"`
+	User      string    `json:"user,omitempty" xml:"user,omitempty" yaml:"user,omitempty"`
+	Started   time.Time `json:"started" xml:"started" yaml:"started"`
+	Bytes     int64     `json:"bytes" xml:"bytes" yaml:"bytes"`
+
+	// Speed bytes per second since the start
+	Speed float64 `json:"speed" xml:"speed" yaml:"speed"`
+}
+
+// ActiveDownload the state of a download in progress
+type ActiveDownload struct {
+	Download
+
+	bytes  atomic.Int64
+	kicked atomic.Bool
+	cancel context.CancelFunc
+}
+
+// Add counts the bytes sent
+func (d *ActiveDownload) Add(n int) {
+	d.bytes.Add(int64(n))
+}
+
+// Sent returns the bytes sent
+func (d *ActiveDownload) Sent() int64 {
+	return d.bytes.Load()
+}
+
+// Kicked reports whether the download has been kicked
+func (d *ActiveDownload) Kicked() bool {
+	return d.kicked.Load()
+}
+
+// Counter the requests and the bytes of a file or a client
+type Counter struct {
+	Key      string `json:"key" xml:"key" yaml:"key"`
+	Requests int64  `json:"requests" xml:"requests" yaml:"requests"`
+	Bytes    int64  `json:"bytes" xml:"bytes" yaml:"bytes"`
+}
+
+// Error a failed request
+type Error struct {
+	Time      time.Time `json:"time" xml:"time" yaml:"time"`
+	Status    int       `json:"status" xml:"status" yaml:"status"`
+	Method    string    `json:"method" xml:"method" yaml:"method"`
+	Path      string    `json:"path" xml:"path" yaml:"path"`
+	Client    string    `json:"client" xml:"client" yaml:"client"`
+	RequestID string    `json:"requestid,omitempty" xml:"requestid,omitempty" yaml:"requestid,omitempty"`
+	Error     string    `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Limited a client rejected by a limiter
+type Limited struct {
+	Client  string    `json:"client" xml:"client" yaml:"client"`
+	Limiter string    `json:"limiter" xml:"limiter" yaml:"limiter"`
+	Count   int64     `json:"count" xml:"count" yaml:"count"`
+	Last    time.Time `json:"last" xml:"last" yaml:"last"`
+}
+
+// Tracker tracks the live status of the server
+type Tracker struct {
+	started     time.Time
+	connections atomic.Int64
+
+	mu        sync.Mutex
+	downloads map[string]*ActiveDownload
+	files     map[string]*Counter
+	clients   map[string]*Counter
+	errors    []Error
+	limited   map[string]*Limited
+}
+
+var defaultTracker = NewTracker()
+
+// NewTracker NewTracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		started:   time.Now(),
+		downloads: map[string]*ActiveDownload{},
+		files:     map[string]*Counter{},
+		clients:   map[string]*Counter{},
+		limited:   map[string]*Limited{},
+	}
+}
+
+// Default returns the tracker of the server
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// Started the start time of the tracker
+func (t *Tracker) Started() time.Time {
+	return t.started
+}
+
+// ConnState counts the open connections, it is a http.Server ConnState hook
+func (t *Tracker) ConnState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		t.connections.Add(1)
+	case http.StateHijacked, http.StateClosed:
+		t.connections.Add(-1)
+	}
+}
+
+// Connections the number of open connections
+func (t *Tracker) Connections() int64 {
+	return t.connections.Load()
+}
+
+// Begin tracks a download under a new ID, cancel is called when it is kicked
+func (t *Tracker) Begin(requestID string, path string, client string, user string, cancel context.CancelFunc) *ActiveDownload {
+	d := &ActiveDownload{
+		Download: Download{ID: tools.NewUUID(), RequestID: requestID, Path: path, Client: client, User: user, Started: time.Now()},
+		cancel:   cancel,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.downloads[d.ID] = d
+
+	return d
+}
+
+// End stops tracking the download, and counts it for its file.
+// A partial one, eg: a range of the file, only counts its bytes, not a download.
+func (t *Tracker) End(d *ActiveDownload, status int, partial bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.downloads, d.ID)
+
+	if status != http.StatusOK && status != http.StatusPartialContent {
+		return
+	}
+
+	if partial {
+		count(t.files, d.Path, 0, d.bytes.Load())
+		return
+	}
+
+	count(t.files, d.Path, 1, d.bytes.Load())
+}
+
+// Kick stops an active download
+func (t *Tracker) Kick(id string) bool {
+	t.mu.Lock()
+	d, ok := t.downloads[id]
+	t.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	d.kicked.Store(true)
+
+	if d.cancel != nil {
+		d.cancel()
+	}
+
+	return true
+}
+
+// Downloads the active downloads, the oldest first
+func (t *Tracker) Downloads() []Download {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	downloads := make([]Download, 0, len(t.downloads))
+
+	for _, d := range t.downloads {
+		download := d.Download
+		download.Bytes = d.bytes.Load()
+
+		if elapsed := now.Sub(download.Started).Seconds(); elapsed > 0 {
+			download.Speed = float64(download.Bytes) / elapsed
+		}
+
+		downloads = append(downloads, download)
+	}
+
+	sort.Slice(downloads, func(i, j int) bool { return downloads[i].Started.Before(downloads[j].Started) })
+
+	return downloads
+}
+
+// Request counts a request and its bytes for its client
+func (t *Tracker) Request(client string, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count(t.clients, client, 1, bytes)
+}
+
+// Error records a failed request
+func (t *Tracker) Error(e Error) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errors = append(t.errors, e)
+
+	if len(t.errors) > MaxRecentErrors {
+		t.errors = t.errors[len(t.errors)-MaxRecentErrors:]
+	}
+}
+
+// Errors the recent errors, the latest first
+func (t *Tracker) Errors() []Error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	errs := make([]Error, len(t.errors))
+
+	for i, e := range t.errors {
+		errs[len(errs)-1-i] = e
+	}
+
+	return errs
+}
+
+// Limit records a client rejected by a limiter
+func (t *Tracker) Limit(client string, limiter string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := limiter + ":" + client
+
+	l, ok := t.limited[key]
+
+	if !ok {
+		if len(t.limited) >= MaxTopEntries {
+			t.limited = map[string]*Limited{}
+		}
+
+		l = &Limited{Client: client, Limiter: limiter}
+		t.limited[key] = l
+	}
+
+	l.Count++
+	l.Last = time.Now()
+}
+
+// LimitedClients the clients rejected by a limiter, the latest first
+func (t *Tracker) LimitedClients() []Limited {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limited := make([]Limited, 0, len(t.limited))
+
+	for _, l := range t.limited {
+		limited = append(limited, *l)
+	}
+
+	sort.Slice(limited, func(i, j int) bool { return limited[i].Last.After(limited[j].Last) })
+
+	return limited
+}
+
+// TopFiles the n most downloaded files
+func (t *Tracker) TopFiles(n int) []Counter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return top(t.files, n)
+}
+
+// TopClients the n clients with the most requests
+func (t *Tracker) TopClients(n int) []Counter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return top(t.clients, n)
+}
+
+func count(counters map[string]*Counter, key string, requests int64, bytes int64) {
+	c, ok := counters[key]
+
+	if !ok {
+		if len(counters) >= MaxTopEntries {
+			for k := range counters {
+				delete(counters, k)
+			}
+		}
+
+		c = &Counter{Key: key}
+		counters[key] = c
+	}
+
+	c.Requests += requests
+	c.Bytes += bytes
+}
+
+func top(counters map[string]*Counter, n int) []Counter {
+	list := make([]Counter, 0, len(counters))
+
+	for _, c := range counters {
+		list = append(list, *c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Requests != list[j].Requests {
+			return list[i].Requests > list[j].Requests
+		}
+
+		return list[i].Key < list[j].Key
+	})
+
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+
+	return list
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloads(t *testing.T) {
+	tracker := NewTracker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := tracker.Begin("1", "/a.iso", "10.0.0.1", "", cancel)
+	d.Add(100)
+
+	// the same request id, chosen by the client
+	same := tracker.Begin("1", "/b.iso", "10.0.0.2", "", nil)
+	assert.NotEqual(t, d.ID, same.ID)
+
+	downloads := tracker.Downloads()
+	assert.Len(t, downloads, 2)
+
+	for _, download := range downloads {
+		assert.Equal(t, "1", download.RequestID)
+
+		if download.ID == d.ID {
+			assert.Equal(t, int64(100), download.Bytes)
+		}
+	}
+
+	assert.True(t, tracker.Kick(d.ID))
+	assert.False(t, tracker.Kick("1"))
+	assert.False(t, same.Kicked())
+	assert.True(t, d.Kicked())
+	assert.Error(t, ctx.Err())
+
+	d.Add(10)
+
+	tracker.End(d, http.StatusOK, false)
+	tracker.End(same, http.StatusNotFound, false)
+	assert.Empty(t, tracker.Downloads())
+
+	other := tracker.Begin("2", "/b.iso", "10.0.0.2", "", nil)
+	tracker.End(other, http.StatusOK, false)
+
+	again := tracker.Begin("3", "/b.iso", "10.0.0.2", "", nil)
+	tracker.End(again, http.StatusNotFound, false)
+
+	// a range only adds its bytes
+	segment := tracker.Begin("4", "/a.iso", "10.0.0.2", "", nil)
+	segment.Add(5)
+	tracker.End(segment, http.StatusPartialContent, true)
+
+	files := tracker.TopFiles(1)
+	assert.Equal(t, []Counter{{Key: "/a.iso", Requests: 1, Bytes: 115}}, files)
+}
+
+func TestErrorsAndLimits(t *testing.T) {
+	tracker := NewTracker()
+
+	for i := 0; i < MaxRecentErrors+10; i++ {
+		tracker.Error(Error{Status: 500, Path: fmt.Sprintf("/%d", i)})
+	}
+
+	errs := tracker.Errors()
+	assert.Len(t, errs, MaxRecentErrors)
+	assert.Equal(t, fmt.Sprintf("/%d", MaxRecentErrors+9), errs[0].Path)
+
+	tracker.Limit("10.0.0.1", "requests")
+	tracker.Limit("10.0.0.1", "requests")
+
+	limited := tracker.LimitedClients()
+	assert.Len(t, limited, 1)
+	assert.Equal(t, int64(2), limited[0].Count)
+
+	tracker.Request("10.0.0.1", 10)
+	tracker.Request("10.0.0.2", 10)
+	tracker.Request("10.0.0.2", 10)
+
+	assert.Equal(t, "10.0.0.2", tracker.TopClients(10)[0].Key)
+}
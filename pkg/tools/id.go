@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// crockford the alphabet of the ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewUUID returns a random UUID (version 4)
+func NewUUID() string {
+	return uuid.New().String()
+}
+
+// NewULID returns a ULID, 48 bits of milliseconds and 80 random bits,
+// which sorts by creation time. See https://github.com/ulid/spec
+func NewULID() string {
+	var id [16]byte
+
+	var ms [8]byte
+
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+
+	copy(id[:6], ms[2:])
+	rand.Read(id[6:])
+
+	// 128 bits in 26 characters of 5 bits, the first one only has 3 bits
+	var out [26]byte
+
+	var acc uint64
+	var bits uint
+	pos := len(out) - 1
+
+	for i := len(id) - 1; i >= 0; i-- {
+		acc |= uint64(id[i]) << bits
+		bits += 8
+
+		for bits >= 5 && pos >= 0 {
+			out[pos] = crockford[acc&31]
+			acc >>= 5
+			bits -= 5
+			pos--
+		}
+	}
+
+	if pos >= 0 {
+		out[pos] = crockford[acc&31]
+	}
+
+	return string(out[:])
+}
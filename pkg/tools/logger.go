@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// logLevel is shared by all the handlers, so that it can be changed at runtime
+	logLevel = new(slog.LevelVar)
+
+	rootLogger atomic.Pointer[slog.Logger]
+)
+
+func init() {
+	SetLogger(slog.New(NewLogHandler("text", os.Stdout)))
+}
+
+// LogLevel the level of the logger, it can be changed at runtime
+func LogLevel() *slog.LevelVar {
+	return logLevel
+}
+
+// ParseLogLevel parses debug, info, warn or error
+func ParseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q, it should be debug, info, warn or error", s)
+	}
+
+	return level, nil
+}
+
+// NewLogHandler returns a text or json handler writing to w, at the level of LogLevel
+func NewLogHandler(format string, w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{Level: logLevel}
+
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.NewTextHandler(w, opts)
+}
+
+// SetLogger replaces the root logger, it also becomes the slog and log default
+func SetLogger(logger *slog.Logger) {
+	rootLogger.Store(logger)
+	slog.SetDefault(logger)
+}
+
+// Logger returns the logger of a component, eg: Logger("middlewares")
+func Logger(component string) *slog.Logger {
+	return rootLogger.Load().With("component", component)
+}
+
+// Fatal logs the message at error level, and exits
+func Fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// TeeHandler sends the records to all the handlers which are enabled for their level
+type TeeHandler []slog.Handler
+
+// Enabled Enabled
+func (t TeeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handle Handle
+func (t TeeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			if e := h.Handle(ctx, r.Clone()); e != nil {
+				err = e
+			}
+		}
+	}
+
+	return err
+}
+
+// WithAttrs WithAttrs
+func (t TeeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(TeeHandler, 0, len(t))
+
+	for _, h := range t {
+		handlers = append(handlers, h.WithAttrs(attrs))
+	}
+
+	return handlers
+}
+
+// WithGroup WithGroup
+func (t TeeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(TeeHandler, 0, len(t))
+
+	for _, h := range t {
+		handlers = append(handlers, h.WithGroup(name))
+	}
+
+	return handlers
+}
+
+// LevelHandler only lets the records at or above level through
+type LevelHandler struct {
+	Level   slog.Leveler
+	Handler slog.Handler
+}
+
+// Enabled Enabled
+func (h LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.Level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+// Handle Handle
+func (h LevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs WithAttrs
+func (h LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return LevelHandler{Level: h.Level, Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup WithGroup
+func (h LevelHandler) WithGroup(name string) slog.Handler {
+	return LevelHandler{Level: h.Level, Handler: h.Handler.WithGroup(name)}
+}
+
+// LogWriter returns a writer which logs every write as one record,
+// used to route the output of gin and other libraries through the logger.
+func LogWriter(component string, level slog.Level) io.Writer {
+	return &logWriter{component: component, level: level}
+}
+
+type logWriter struct {
+	component string
+	level     slog.Level
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+
+	// gin prefixes its own messages
+	msg = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(msg, "[GIN-debug]"), "[GIN]"))
+
+	level := w.level
+
+	if strings.HasPrefix(msg, "[WARNING]") {
+		level = slog.LevelWarn
+		msg = strings.TrimSpace(strings.TrimPrefix(msg, "[WARNING]"))
+	}
+
+	if msg != "" {
+		Logger(w.component).Log(context.Background(), level, msg)
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,15 @@
+package tools
+
+import (
+	"io"
+	"os"
+)
+
+// DefaultGinWriter  Log for gin app
+var DefaultGinWriter io.Writer = os.Stdout
+
+// DefaultAccessWriter  Log for gin app
+var DefaultAccessWriter io.Writer = os.Stdout
+
+// DefaultErrorWriter  Log for gin app
+var DefaultErrorWriter io.Writer = os.Stderr
@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"snowdream.tech/http-server/pkg/configs"
+	"snowdream.tech/http-server/pkg/env"
+)
+
+// Name the instrumentation name of the tracer
+const Name = "snowdream.tech/http-server"
+
+// Exporters
+const (
+	// ExporterGRPC OTLP over gRPC
+	ExporterGRPC = "otlp-grpc"
+
+	// ExporterHTTP OTLP over HTTP, with protobuf payloads
+	ExporterHTTP = "otlp-http"
+)
+
+// Tracer returns the tracer of the server, it does nothing until Init
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Start starts a span, eg: ctx, span := tracing.Start(ctx, "fs.open")
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Init installs the tracer provider, which exports the spans to the collector,
+// and the W3C trace context propagator.
+// The returned function flushes the pending spans and stops the exporter.
+func Init(ctx context.Context, conf *configs.TracingConfig) (func(context.Context) error, error) {
+	exporter, err := NewExporter(ctx, conf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := conf.ServiceName
+
+	if name == "" {
+		name = env.ProjectName
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", name),
+		attribute.String("service.version", env.GitTag),
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// NewExporter returns the OTLP exporter of the config
+func NewExporter(ctx context.Context, conf *configs.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case "", ExporterGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint), otlptracegrpc.WithHeaders(conf.Headers)}
+
+		if conf.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.Endpoint), otlptracehttp.WithHeaders(conf.Headers)}
+
+		if conf.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	return nil, fmt.Errorf("unknown exporter %q, it should be %s or %s", conf.Exporter, ExporterGRPC, ExporterHTTP)
+}
+
+// Transport traces the requests to the upstream servers, and propagates the trace context to them.
+// The base transport defaults to http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.full", req.URL.Redacted()),
+		),
+	)
+
+	req = req.Clone(ctx)
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+
+	End(span, err)
+
+	return resp, err
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"snowdream.tech/http-server/pkg/configs"
+)
+
+// collector is an in-process stand-in of an OTLP/HTTP collector
+type collector struct {
+	mu    sync.Mutex
+	spans map[string]string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	var req coltracepb.ExportTraceServiceRequest
+
+	if r.URL.Path != "/v1/traces" || proto.Unmarshal(body, &req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans[span.Name] = string(span.TraceId)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(nil)
+}
+
+func TestExportAndPropagation(t *testing.T) {
+	col := &collector{spans: map[string]string{}}
+
+	server := httptest.NewServer(col)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+
+	ctx := context.Background()
+
+	shutdown, err := Init(ctx, &configs.TracingConfig{
+		Exporter:    ExporterHTTP,
+		Endpoint:    u.Host,
+		Insecure:    true,
+		SampleRatio: 1,
+	})
+	assert.NoError(t, err)
+
+	var traceparent string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, span := Start(ctx, "GET /*filepath")
+
+	_, child := Start(ctx, "fs.open")
+	End(child, nil)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	// the upstream server joins the trace
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+
+	col.mu.Lock()
+	defer col.mu.Unlock()
+
+	assert.Contains(t, col.spans, "GET /*filepath")
+	assert.Contains(t, col.spans, "fs.open")
+	assert.Contains(t, col.spans, "HTTP GET")
+	assert.Equal(t, col.spans["GET /*filepath"], col.spans["fs.open"])
+
+	_, err = NewExporter(context.Background(), &configs.TracingConfig{Exporter: "zipkin"})
+	assert.Error(t, err)
+}